@@ -18,9 +18,7 @@ package koios
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"net/http"
 	"net/url"
 )
 
@@ -90,7 +88,7 @@ type (
 		Data []AccountRewards `json:"response"`
 	}
 
-	// AccountUpdatesResponse represents response from `/account_rewards` endpoint.
+	// AccountUpdatesResponse represents response from `/account_updates` endpoint.
 	AccountUpdatesResponse struct {
 		Response
 		Data []AccountAction `json:"response"`
@@ -115,18 +113,28 @@ type (
 	}
 )
 
-// GetTip returns the tip info about the latest block seen by chain.
-func (c *Client) GetAccountList(ctx context.Context) (res *AccountListResponse, err error) {
-	res = &AccountListResponse{}
-	rsp, err := c.request(ctx, &res.Response, "GET", "/account_list", nil, nil, nil)
-	if err != nil {
-		res.applyError(nil, err)
-		return
-	}
+// GetAccountList returns a list of all accounts (paginated).
+// Use AccountListIterator to walk through all pages.
+func (c *Client) GetAccountList(ctx context.Context, opts ...CallOption) (res *AccountListResponse, err error) {
+	return c.getAccountList(ctx, nil, opts...)
+}
 
-	body, err := readResponseBody(rsp)
+// AccountListIterator returns iterator over all accounts.
+func (c *Client) AccountListIterator(ctx context.Context, opts ...CallOption) *Iterator[StakeAddress] {
+	return newIterator(ctx, c, func(ctx context.Context, query url.Values) ([]StakeAddress, *Response, error) {
+		res, err := c.getAccountList(ctx, query, opts...)
+		return res.Data, &res.Response, err
+	})
+}
+
+func (c *Client) getAccountList(
+	ctx context.Context,
+	query url.Values,
+	opts ...CallOption,
+) (res *AccountListResponse, err error) {
+	res = &AccountListResponse{}
+	rsp, err := c.request(ctx, &res.Response, "GET", "/account_list", nil, query, nil, opts...)
 	if err != nil {
-		res.applyError(body, err)
 		return
 	}
 
@@ -134,13 +142,7 @@ func (c *Client) GetAccountList(ctx context.Context) (res *AccountListResponse,
 		ID StakeAddress `json:"id"`
 	}{}
 
-	if err = json.Unmarshal(body, &accs); err != nil {
-		res.applyError(body, err)
-		return
-	}
-
-	if rsp.StatusCode != http.StatusOK {
-		res.applyError(body, err)
+	if err = readAndUnmarshalResponse(rsp, &res.Response, &accs); err != nil {
 		return
 	}
 	if len(accs) > 0 {
@@ -148,47 +150,41 @@ func (c *Client) GetAccountList(ctx context.Context) (res *AccountListResponse,
 			res.Data = append(res.Data, a.ID)
 		}
 	}
-	return res, nil
+	return
 }
 
 // GetAccountInfo returns the account info of any (payment or staking) address.
 //nolint: dupl
-func (c *Client) GetAccountInfo(ctx context.Context, addr Address) (res *AccountInfoResponse, err error) {
+func (c *Client) GetAccountInfo(
+	ctx context.Context,
+	addr Address,
+	opts ...CallOption,
+) (res *AccountInfoResponse, err error) {
 	res = &AccountInfoResponse{}
 	if len(addr) == 0 {
-		err = ErrNoAddress
-		res.applyError(nil, err)
+		err = res.applyError(nil, ErrNoAddress)
+		return
+	}
+	if err = c.validateAddress(string(addr), opts); err != nil {
+		err = res.applyError(nil, err)
 		return
 	}
 	params := url.Values{}
 	params.Set("_address", string(addr))
 
-	rsp, err := c.request(ctx, &res.Response, "GET", "/account_info", nil, params, nil)
-	if err != nil {
-		return
-	}
-	body, err := readResponseBody(rsp)
+	rsp, err := c.request(ctx, &res.Response, "GET", "/account_info", nil, params, nil, opts...)
 	if err != nil {
-		res.applyError(nil, err)
 		return
 	}
-
 	addrs := []AccountInfo{}
 
-	if err = json.Unmarshal(body, &addrs); err != nil {
-		res.applyError(body, err)
-		return
-	}
-
-	if rsp.StatusCode != http.StatusOK {
-		res.applyError(body, err)
+	if err = readAndUnmarshalResponse(rsp, &res.Response, &addrs); err != nil {
 		return
 	}
 	if len(addrs) == 1 {
 		res.Data = &addrs[0]
 	}
-	res.ready()
-	return res, nil
+	return
 }
 
 // GetAccountRewards retruns the full rewards history (including MIR)
@@ -197,30 +193,24 @@ func (c *Client) GetAccountRewards(
 	ctx context.Context,
 	addr StakeAddress,
 	epoch *EpochNo,
+	opts ...CallOption,
 ) (res *AccountRewardsResponse, err error) {
 	res = &AccountRewardsResponse{}
+	if err = c.validateAddress(string(addr), opts); err != nil {
+		err = res.applyError(nil, err)
+		return
+	}
 	params := url.Values{}
 	params.Set("_stake_address", string(addr))
 	if epoch != nil {
 		params.Set("_epoch_no", fmt.Sprint(*epoch))
 	}
-	rsp, err := c.request(ctx, &res.Response, "GET", "/account_rewards", nil, params, nil)
-	if err != nil {
-		res.applyError(nil, err)
-		return
-	}
-	body, err := readResponseBody(rsp)
+	rsp, err := c.request(ctx, &res.Response, "GET", "/account_rewards", nil, params, nil, opts...)
 	if err != nil {
-		res.applyError(body, err)
 		return
 	}
-
-	if err = json.Unmarshal(body, &res.Data); err != nil {
-		res.applyError(body, err)
-		return
-	}
-	res.ready()
-	return res, nil
+	err = readAndUnmarshalResponse(rsp, &res.Response, &res.Data)
+	return
 }
 
 // GetAccountUpdates (History) retruns the account updates
@@ -228,61 +218,47 @@ func (c *Client) GetAccountRewards(
 func (c *Client) GetAccountUpdates(
 	ctx context.Context,
 	addr StakeAddress,
+	opts ...CallOption,
 ) (res *AccountUpdatesResponse, err error) {
 	res = &AccountUpdatesResponse{}
+	if err = c.validateAddress(string(addr), opts); err != nil {
+		err = res.applyError(nil, err)
+		return
+	}
 	params := url.Values{}
 	params.Set("_stake_address", string(addr))
 
-	rsp, err := c.request(ctx, &res.Response, "GET", "/account_updates", nil, params, nil)
-	if err != nil {
-		res.applyError(nil, err)
-		return
-	}
-	body, err := readResponseBody(rsp)
+	rsp, err := c.request(ctx, &res.Response, "GET", "/account_updates", nil, params, nil, opts...)
 	if err != nil {
-		res.applyError(body, err)
 		return
 	}
-
-	if err = json.Unmarshal(body, &res.Data); err != nil {
-		res.applyError(body, err)
-		return
-	}
-	res.ready()
-	return res, nil
+	err = readAndUnmarshalResponse(rsp, &res.Response, &res.Data)
+	return
 }
 
 // GetAccountAddresses retruns all addresses associated with an account.
 func (c *Client) GetAccountAddresses(
 	ctx context.Context,
 	addr StakeAddress,
+	opts ...CallOption,
 ) (res *AccountAddressesResponse, err error) {
 	res = &AccountAddressesResponse{}
+	if err = c.validateAddress(string(addr), opts); err != nil {
+		err = res.applyError(nil, err)
+		return
+	}
 	params := url.Values{}
 	params.Set("_address", string(addr))
 
-	rsp, err := c.request(ctx, &res.Response, "GET", "/account_addresses", nil, params, nil)
+	rsp, err := c.request(ctx, &res.Response, "GET", "/account_addresses", nil, params, nil, opts...)
 	if err != nil {
-		res.applyError(nil, err)
 		return
 	}
-	body, err := readResponseBody(rsp)
-	if err != nil {
-		res.applyError(body, err)
-		return
-	}
-
 	addrs := []struct {
 		Addr Address `json:"address"`
 	}{}
 
-	if err = json.Unmarshal(body, &addrs); err != nil {
-		res.applyError(body, err)
-		return
-	}
-
-	if rsp.StatusCode != http.StatusOK {
-		res.applyError(body, err)
+	if err = readAndUnmarshalResponse(rsp, &res.Response, &addrs); err != nil {
 		return
 	}
 	if len(addrs) > 0 {
@@ -290,72 +266,49 @@ func (c *Client) GetAccountAddresses(
 			res.Data = append(res.Data, a.Addr)
 		}
 	}
-	res.ready()
-	return res, nil
+	return
 }
 
 // GetAccountAssets retruns all the native asset balance of an account.
 func (c *Client) GetAccountAssets(
 	ctx context.Context,
 	addr StakeAddress,
+	opts ...CallOption,
 ) (res *AccountAssetsResponse, err error) {
 	res = &AccountAssetsResponse{}
+	if err = c.validateAddress(string(addr), opts); err != nil {
+		err = res.applyError(nil, err)
+		return
+	}
 	params := url.Values{}
 	params.Set("_address", string(addr))
 
-	rsp, err := c.request(ctx, &res.Response, "GET", "/account_assets", nil, params, nil)
-	if err != nil {
-		res.applyError(nil, err)
-		return
-	}
-	body, err := readResponseBody(rsp)
+	rsp, err := c.request(ctx, &res.Response, "GET", "/account_assets", nil, params, nil, opts...)
 	if err != nil {
-		res.applyError(body, err)
 		return
 	}
-
-	if err = json.Unmarshal(body, &res.Data); err != nil {
-		res.applyError(body, err)
-		return
-	}
-
-	if rsp.StatusCode != http.StatusOK {
-		res.applyError(body, err)
-		return
-	}
-	res.ready()
-	return res, nil
+	err = readAndUnmarshalResponse(rsp, &res.Response, &res.Data)
+	return
 }
 
 // GetAccountHistory retruns the staking history of an account.
 func (c *Client) GetAccountHistory(
 	ctx context.Context,
 	addr StakeAddress,
+	opts ...CallOption,
 ) (res *AccountHistoryResponse, err error) {
 	res = &AccountHistoryResponse{}
+	if err = c.validateAddress(string(addr), opts); err != nil {
+		err = res.applyError(nil, err)
+		return
+	}
 	params := url.Values{}
 	params.Set("_address", string(addr))
 
-	rsp, err := c.request(ctx, &res.Response, "GET", "/account_history", nil, params, nil)
+	rsp, err := c.request(ctx, &res.Response, "GET", "/account_history", nil, params, nil, opts...)
 	if err != nil {
-		res.applyError(nil, err)
-		return
-	}
-	body, err := readResponseBody(rsp)
-	if err != nil {
-		res.applyError(body, err)
-		return
-	}
-
-	if err = json.Unmarshal(body, &res.Data); err != nil {
-		res.applyError(body, err)
-		return
-	}
-
-	if rsp.StatusCode != http.StatusOK {
-		res.applyError(body, err)
 		return
 	}
-	res.ready()
-	return res, nil
+	err = readAndUnmarshalResponse(rsp, &res.Response, &res.Data)
+	return
 }
@@ -17,11 +17,11 @@
 package koios
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
-	"io"
-	"net/http"
 	"net/url"
+	"sort"
 )
 
 type (
@@ -81,65 +81,105 @@ type (
 		Quantity Lovelace `json:"quantity"`
 	}
 
-	// AddressAssetsResponse represents response from `/address_info` endpoint.
+	// AddressAssetsResponse represents response from `/address_assets` endpoint.
 	AddressAssetsResponse struct {
 		Response
 		Data []AddressAsset `json:"response"`
 	}
+
+	// addressTx is item of `/address_txs` response.
+	addressTx struct {
+		Hash        TxHash `json:"tx_hash"`
+		BlockHeight int    `json:"block_height"`
+	}
 )
 
 // GetAddressInfo returns address info - balance,
 // associated stake address (if any) and UTxO set.
 //nolint: dupl
-func (c *Client) GetAddressInfo(ctx context.Context, addr Address) (res *AddressInfoResponse, err error) {
+func (c *Client) GetAddressInfo(
+	ctx context.Context,
+	addr Address,
+	opts ...CallOption,
+) (res *AddressInfoResponse, err error) {
 	res = &AddressInfoResponse{}
 	if len(addr) == 0 {
-		err = ErrNoAddress
-		res.applyError(nil, err)
+		err = res.applyError(nil, ErrNoAddress)
+		return
+	}
+	if err = c.validateAddress(string(addr), opts); err != nil {
+		err = res.applyError(nil, err)
 		return
 	}
 	params := url.Values{}
 	params.Set("_address", string(addr))
 
-	rsp, err := c.request(ctx, &res.Response, "GET", "/address_info", nil, params, nil)
-	if err != nil {
-		return
-	}
-	body, err := readResponseBody(rsp)
+	rsp, err := c.request(ctx, &res.Response, "GET", "/address_info", nil, params, nil, opts...)
 	if err != nil {
-		res.applyError(nil, err)
 		return
 	}
-
 	addrs := []AddressInfo{}
 
-	if err = json.Unmarshal(body, &addrs); err != nil {
-		res.applyError(body, err)
-		return
-	}
-
-	if rsp.StatusCode != http.StatusOK {
-		res.applyError(body, err)
+	if err = readAndUnmarshalResponse(rsp, &res.Response, &addrs); err != nil {
 		return
 	}
 	if len(addrs) == 1 {
 		res.Data = &addrs[0]
 	}
-	res.ready()
-	return res, nil
+	return
 }
 
 // GetAddressTxs returns the transaction hash list of input address array,
 // optionally filtering after specified block height (inclusive).
+// Transactions are sorted by block height descending.
 //nolint: dupl
-func (c *Client) GetAddressTxs(ctx context.Context, addrs []Address, h uint64) (res *AddressTxsResponse, err error) {
+func (c *Client) GetAddressTxs(
+	ctx context.Context,
+	addrs []Address,
+	h uint64,
+	opts ...CallOption,
+) (res *AddressTxsResponse, err error) {
 	res = &AddressTxsResponse{}
 	if len(addrs) == 0 {
-		err = ErrNoAddress
-		res.applyError(nil, err)
+		err = res.applyError(nil, ErrNoAddress)
 		return
 	}
+	for _, addr := range addrs {
+		if err = c.validateAddress(string(addr), opts); err != nil {
+			err = res.applyError(nil, err)
+			return
+		}
+	}
+
+	txs, err := bulk(ctx, c, &res.Response, addrs, nil,
+		func(ctx context.Context, chunk []Address) ([]addressTx, *Response, error) {
+			return c.getAddressTxs(ctx, chunk, h, opts)
+		},
+	)
+
+	// results of chunks are merged in the order of single response,
+	// which is sorted by block height descending.
+	sort.SliceStable(txs, func(i, j int) bool {
+		return txs[i].BlockHeight > txs[j].BlockHeight
+	})
+	// same transaction may be returned for addresses in different chunks.
+	seen := make(map[TxHash]bool, len(txs))
+	for _, tx := range txs {
+		if !seen[tx.Hash] {
+			seen[tx.Hash] = true
+			res.Data = append(res.Data, tx.Hash)
+		}
+	}
+	return
+}
 
+func (c *Client) getAddressTxs(
+	ctx context.Context,
+	addrs []Address,
+	h uint64,
+	opts []CallOption,
+) ([]addressTx, *Response, error) {
+	res := &Response{}
 	var payload = struct {
 		Adresses         []Address `json:"_addresses"`
 		AfterBlockHeight uint64    `json:"_after_block_height,omitempty"`
@@ -148,78 +188,47 @@ func (c *Client) GetAddressTxs(ctx context.Context, addrs []Address, h uint64) (
 		AfterBlockHeight: h,
 	}
 
-	rpipe, w := io.Pipe()
-	go func() {
-		_ = json.NewEncoder(w).Encode(payload)
-		defer w.Close()
-	}()
-
-	rsp, err := c.request(ctx, &res.Response, "POST", "/address_txs", rpipe, nil, nil)
-	if err != nil {
-		return
-	}
-	body, err := readResponseBody(rsp)
+	data, err := json.Marshal(payload)
 	if err != nil {
-		res.applyError(nil, err)
-		return
-	}
-
-	atxs := []struct {
-		Hash TxHash `json:"tx_hash"`
-	}{}
-
-	if err = json.Unmarshal(body, &atxs); err != nil {
-		res.applyError(body, err)
-		return
+		return nil, res, res.applyError(nil, err)
 	}
 
-	if rsp.StatusCode != http.StatusOK {
-		res.applyError(body, err)
-		return
+	rsp, err := c.request(ctx, res, "POST", "/address_txs", bytes.NewReader(data), nil, nil, opts...)
+	if err != nil {
+		return nil, res, err
 	}
-	if len(atxs) > 0 {
-		for _, tx := range atxs {
-			res.Data = append(res.Data, tx.Hash)
-		}
+	txs := []addressTx{}
+	if err = readAndUnmarshalResponse(rsp, res, &txs); err != nil {
+		return nil, res, err
 	}
-	res.ready()
-	return res, nil
+	return txs, res, nil
 }
 
 // GetAddressAssets returns the list of all the assets (policy, name and quantity)
 // for a given address.
-func (c *Client) GetAddressAssets(ctx context.Context, addr Address) (res *AddressAssetsResponse, err error) {
+func (c *Client) GetAddressAssets(
+	ctx context.Context,
+	addr Address,
+	opts ...CallOption,
+) (res *AddressAssetsResponse, err error) {
 	res = &AddressAssetsResponse{}
 	if len(addr) == 0 {
-		err = ErrNoAddress
-		res.applyError(nil, err)
+		err = res.applyError(nil, ErrNoAddress)
+		return
+	}
+	if err = c.validateAddress(string(addr), opts); err != nil {
+		err = res.applyError(nil, err)
 		return
 	}
 	params := url.Values{}
 	params.Set("_address", string(addr))
 
-	rsp, err := c.request(ctx, &res.Response, "GET", "/address_assets", nil, params, nil)
-	if err != nil {
-		return
-	}
-	body, err := readResponseBody(rsp)
+	rsp, err := c.request(ctx, &res.Response, "GET", "/address_assets", nil, params, nil, opts...)
 	if err != nil {
-		res.applyError(nil, err)
-		return
-	}
-
-	if err = json.Unmarshal(body, &res.Data); err != nil {
-		res.applyError(body, err)
-		return
-	}
-
-	if rsp.StatusCode != http.StatusOK {
-		res.applyError(body, err)
 		return
 	}
-
-	res.ready()
-	return res, nil
+	err = readAndUnmarshalResponse(rsp, &res.Response, &res.Data)
+	return
 }
 
 // GetCredentialTxs returns the transaction hash list of input
@@ -229,11 +238,11 @@ func (c *Client) GetCredentialTxs(
 	ctx context.Context,
 	creds []PaymentCredential,
 	h uint64,
+	opts ...CallOption,
 ) (res *CredentialTxsResponse, err error) {
 	res = &CredentialTxsResponse{}
 	if len(creds) == 0 {
-		err = ErrNoAddress
-		res.applyError(nil, err)
+		err = res.applyError(nil, ErrNoAddress)
 		return
 	}
 
@@ -245,33 +254,21 @@ func (c *Client) GetCredentialTxs(
 		AfterBlockHeight: h,
 	}
 
-	rpipe, w := io.Pipe()
-	go func() {
-		_ = json.NewEncoder(w).Encode(payload)
-		defer w.Close()
-	}()
-
-	rsp, err := c.request(ctx, &res.Response, "POST", "/credential_txs", rpipe, nil, nil)
+	data, err := json.Marshal(payload)
 	if err != nil {
+		err = res.applyError(nil, err)
 		return
 	}
-	body, err := readResponseBody(rsp)
+
+	rsp, err := c.request(ctx, &res.Response, "POST", "/credential_txs", bytes.NewReader(data), nil, nil, opts...)
 	if err != nil {
-		res.applyError(nil, err)
 		return
 	}
-
 	atxs := []struct {
 		Hash TxHash `json:"tx_hash"`
 	}{}
 
-	if err = json.Unmarshal(body, &atxs); err != nil {
-		res.applyError(body, err)
-		return
-	}
-
-	if rsp.StatusCode != http.StatusOK {
-		res.applyError(body, err)
+	if err = readAndUnmarshalResponse(rsp, &res.Response, &atxs); err != nil {
 		return
 	}
 	if len(atxs) > 0 {
@@ -279,6 +276,5 @@ func (c *Client) GetCredentialTxs(
 			res.Data = append(res.Data, tx.Hash)
 		}
 	}
-	res.ready()
-	return res, nil
+	return
 }
@@ -18,8 +18,6 @@ package koios
 
 import (
 	"context"
-	"encoding/json"
-	"net/http"
 	"net/url"
 )
 
@@ -155,31 +153,33 @@ type (
 	}
 )
 
-// GetTip returns the list of all native assets (paginated).
-func (c *Client) GetAssetList(ctx context.Context) (res *AssetListResponse, err error) {
-	res = &AssetListResponse{}
-	rsp, err := c.request(ctx, &res.Response, "GET", "/asset_list", nil, nil, nil)
-	if err != nil {
-		res.applyError(nil, err)
-		return
-	}
+// GetAssetList returns the list of all native assets (paginated).
+// Use AssetListIterator to walk through all pages.
+func (c *Client) GetAssetList(ctx context.Context, opts ...CallOption) (res *AssetListResponse, err error) {
+	return c.getAssetList(ctx, nil, opts...)
+}
 
-	body, err := readResponseBody(rsp)
-	if err != nil {
-		res.applyError(body, err)
-		return
-	}
+// AssetListIterator returns iterator over all native assets.
+func (c *Client) AssetListIterator(ctx context.Context, opts ...CallOption) *Iterator[AssetListItem] {
+	return newIterator(ctx, c, func(ctx context.Context, query url.Values) ([]AssetListItem, *Response, error) {
+		res, err := c.getAssetList(ctx, query, opts...)
+		return res.Data, &res.Response, err
+	})
+}
 
-	if err = json.Unmarshal(body, &res.Data); err != nil {
-		res.applyError(body, err)
+func (c *Client) getAssetList(
+	ctx context.Context,
+	query url.Values,
+	opts ...CallOption,
+) (res *AssetListResponse, err error) {
+	res = &AssetListResponse{}
+	rsp, err := c.request(ctx, &res.Response, "GET", "/asset_list", nil, query, nil, opts...)
+	if err != nil {
 		return
 	}
 
-	if rsp.StatusCode != http.StatusOK {
-		res.applyError(body, err)
-		return
-	}
-	return res, nil
+	err = readAndUnmarshalResponse(rsp, &res.Response, &res.Data)
+	return
 }
 
 // GetAssetAddressList returns the list of all addresses holding a given asset.
@@ -187,6 +187,7 @@ func (c *Client) GetAssetAddressList(
 	ctx context.Context,
 	policy PolicyID,
 	name AssetName,
+	opts ...CallOption,
 ) (res *AssetAddressListResponse, err error) {
 	res = &AssetAddressListResponse{}
 
@@ -194,28 +195,13 @@ func (c *Client) GetAssetAddressList(
 	params.Set("_asset_policy", string(policy))
 	params.Set("_asset_name", string(name))
 
-	rsp, err := c.request(ctx, &res.Response, "GET", "/asset_address_list", nil, params, nil)
+	rsp, err := c.request(ctx, &res.Response, "GET", "/asset_address_list", nil, params, nil, opts...)
 	if err != nil {
-		res.applyError(nil, err)
 		return
 	}
 
-	body, err := readResponseBody(rsp)
-	if err != nil {
-		res.applyError(body, err)
-		return
-	}
-
-	if err = json.Unmarshal(body, &res.Data); err != nil {
-		res.applyError(body, err)
-		return
-	}
-
-	if rsp.StatusCode != http.StatusOK {
-		res.applyError(body, err)
-		return
-	}
-	return res, nil
+	err = readAndUnmarshalResponse(rsp, &res.Response, &res.Data)
+	return
 }
 
 // GetAssetInfo returns the information of an asset including
@@ -225,6 +211,7 @@ func (c *Client) GetAssetInfo(
 	ctx context.Context,
 	policy PolicyID,
 	name AssetName,
+	opts ...CallOption,
 ) (res *AssetInfoResponse, err error) {
 	res = &AssetInfoResponse{}
 
@@ -232,34 +219,20 @@ func (c *Client) GetAssetInfo(
 	params.Set("_asset_policy", string(policy))
 	params.Set("_asset_name", string(name))
 
-	rsp, err := c.request(ctx, &res.Response, "GET", "/asset_info", nil, params, nil)
-	if err != nil {
-		res.applyError(nil, err)
-		return
-	}
-
-	body, err := readResponseBody(rsp)
+	rsp, err := c.request(ctx, &res.Response, "GET", "/asset_info", nil, params, nil, opts...)
 	if err != nil {
-		res.applyError(body, err)
 		return
 	}
 
 	info := []AssetInfo{}
 
-	if err = json.Unmarshal(body, &info); err != nil {
-		res.applyError(body, err)
-		return
-	}
-
-	if rsp.StatusCode != http.StatusOK {
-		res.applyError(body, err)
+	if err = readAndUnmarshalResponse(rsp, &res.Response, &info); err != nil {
 		return
 	}
 	if len(info) == 1 {
 		res.Data = &info[0]
 	}
-	res.ready()
-	return res, nil
+	return
 }
 
 // GetAssetSummary returns the summary of an asset
@@ -270,6 +243,7 @@ func (c *Client) GetAssetSummary(
 	ctx context.Context,
 	policy PolicyID,
 	name AssetName,
+	opts ...CallOption,
 ) (res *AssetSummaryResponse, err error) {
 	res = &AssetSummaryResponse{}
 
@@ -277,34 +251,20 @@ func (c *Client) GetAssetSummary(
 	params.Set("_asset_policy", string(policy))
 	params.Set("_asset_name", string(name))
 
-	rsp, err := c.request(ctx, &res.Response, "GET", "/asset_summary", nil, params, nil)
-	if err != nil {
-		res.applyError(nil, err)
-		return
-	}
-
-	body, err := readResponseBody(rsp)
+	rsp, err := c.request(ctx, &res.Response, "GET", "/asset_summary", nil, params, nil, opts...)
 	if err != nil {
-		res.applyError(body, err)
 		return
 	}
 
 	summary := []AssetSummary{}
 
-	if err = json.Unmarshal(body, &summary); err != nil {
-		res.applyError(body, err)
-		return
-	}
-
-	if rsp.StatusCode != http.StatusOK {
-		res.applyError(body, err)
+	if err = readAndUnmarshalResponse(rsp, &res.Response, &summary); err != nil {
 		return
 	}
 	if len(summary) == 1 {
 		res.Data = &summary[0]
 	}
-	res.ready()
-	return res, nil
+	return
 }
 
 // GetAssetTxs returns the list of all asset transaction hashes (newest first).
@@ -313,6 +273,7 @@ func (c *Client) GetAssetTxs(
 	ctx context.Context,
 	policy PolicyID,
 	name AssetName,
+	opts ...CallOption,
 ) (res *AssetTxsResponse, err error) {
 	res = &AssetTxsResponse{}
 
@@ -320,32 +281,18 @@ func (c *Client) GetAssetTxs(
 	params.Set("_asset_policy", string(policy))
 	params.Set("_asset_name", string(name))
 
-	rsp, err := c.request(ctx, &res.Response, "GET", "/asset_txs", nil, params, nil)
+	rsp, err := c.request(ctx, &res.Response, "GET", "/asset_txs", nil, params, nil, opts...)
 	if err != nil {
-		res.applyError(nil, err)
-		return
-	}
-
-	body, err := readResponseBody(rsp)
-	if err != nil {
-		res.applyError(body, err)
 		return
 	}
 
 	atxs := []AssetTxs{}
 
-	if err = json.Unmarshal(body, &atxs); err != nil {
-		res.applyError(body, err)
-		return
-	}
-
-	if rsp.StatusCode != http.StatusOK {
-		res.applyError(body, err)
+	if err = readAndUnmarshalResponse(rsp, &res.Response, &atxs); err != nil {
 		return
 	}
 	if len(atxs) == 1 {
 		res.Data = &atxs[0]
 	}
-	res.ready()
-	return res, nil
+	return
 }
@@ -0,0 +1,400 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTokenExpiryWarning is how long before expiry of the auth
+	// token the warning is reported.
+	DefaultTokenExpiryWarning = 72 * time.Hour
+
+	// tokenRefreshMargin is how long before expiry token is requested
+	// again from TokenProvider.
+	tokenRefreshMargin = time.Minute
+)
+
+type (
+	// TokenProvider returns bearer token used to authenticate requests.
+	// It is called before first request, when token is about to expire
+	// and after server rejected the token, use it to rotate tokens.
+	TokenProvider func(ctx context.Context) (string, error)
+
+	// TokenInfo holds claims of the auth token.
+	TokenInfo struct {
+		// Tier of the subscription.
+		Tier int `json:"tier"`
+
+		// ExpiresAt is expiry of the token, zero when token does not expire.
+		ExpiresAt time.Time `json:"expires_at,omitempty"`
+
+		// Address which registered the token.
+		Address string `json:"addr,omitempty"`
+
+		// ProjectID of the token.
+		ProjectID string `json:"project_id,omitempty"`
+	}
+
+	// authState holds auth token of the client.
+	authState struct {
+		mu         sync.Mutex
+		provider   TokenProvider
+		gen        uint64
+		refresh    *tokenRefresh
+		token      string
+		info       TokenInfo
+		warned     bool
+		warnBefore time.Duration
+		onExpiry   func(TokenInfo)
+	}
+
+	// tokenRefresh is call of TokenProvider in progress,
+	// concurrent requests wait for it instead of calling provider.
+	tokenRefresh struct {
+		done    chan struct{}
+		err     error
+		aborted bool
+	}
+
+	// tokenProviderError is error returned by TokenProvider,
+	// it matches ErrAuthToken and wraps error of the provider.
+	tokenProviderError struct {
+		err error
+	}
+
+	// tokenClaims are claims of Koios JWT token.
+	tokenClaims struct {
+		Exp    json.Number `json:"exp"`
+		Tier   json.Number `json:"tier"`
+		Addr   string      `json:"addr"`
+		ProjID string      `json:"projID"`
+	}
+)
+
+// Auth sets JWT bearer token used to authenticate requests to Koios
+// authenticated tiers. Rate limit of the client is not changed,
+// use AuthTierLimits to adjust it to tier of the token.
+func Auth(token string) Option {
+	return func(c *Client) error {
+		info, err := parseToken(token)
+		if err != nil {
+			return err
+		}
+		c.mux.Lock()
+		defer c.mux.Unlock()
+		a := c.authState()
+		a.mu.Lock()
+		a.provider = nil
+		a.gen++
+		a.set(token, info)
+		a.mu.Unlock()
+		c.applyTier(info)
+		return nil
+	}
+}
+
+// AuthProvider sets TokenProvider used to obtain bearer token
+// for authenticated requests, use it to rotate tokens.
+func AuthProvider(provider TokenProvider) Option {
+	return func(c *Client) error {
+		if provider == nil {
+			return ErrAuthProviderNil
+		}
+		c.mux.Lock()
+		defer c.mux.Unlock()
+		a := c.authState()
+		a.mu.Lock()
+		a.provider = provider
+		a.gen++
+		a.set("", TokenInfo{})
+		a.mu.Unlock()
+		return nil
+	}
+}
+
+// AuthTierLimits sets requests per second applied for tier claim of the
+// auth token when rate limit was not set with RateLimit or RateLimiter
+// option. Use it to match limits of your subscription, limits are copied.
+// Rate limit is not changed for tiers missing in limits.
+func AuthTierLimits(limits map[int]uint8) Option {
+	return func(c *Client) error {
+		tiers := make(map[int]uint8, len(limits))
+		for tier, reqps := range limits {
+			tiers[tier] = reqps
+		}
+		c.mux.Lock()
+		defer c.mux.Unlock()
+		c.tierLimits = tiers
+		if c.auth != nil {
+			c.auth.mu.Lock()
+			info, ok := c.auth.info, len(c.auth.token) > 0
+			c.auth.mu.Unlock()
+			if ok {
+				c.applyTier(info)
+			}
+		}
+		return nil
+	}
+}
+
+// AuthExpiryWarning sets fn to be called once per token when token
+// expires in less than before. By default warning is logged with Logger
+// of the client (see Logging) DefaultTokenExpiryWarning before expiry.
+func AuthExpiryWarning(before time.Duration, fn func(TokenInfo)) Option {
+	return func(c *Client) error {
+		c.mux.Lock()
+		defer c.mux.Unlock()
+		a := c.authState()
+		a.mu.Lock()
+		a.warnBefore = before
+		a.onExpiry = fn
+		a.mu.Unlock()
+		return nil
+	}
+}
+
+// TokenInfo returns claims of the current auth token,
+// false is returned when client is not authenticated.
+func (c *Client) TokenInfo() (TokenInfo, bool) {
+	c.mux.RLock()
+	a := c.auth
+	c.mux.RUnlock()
+	if a == nil {
+		return TokenInfo{}, false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.info, len(a.token) > 0
+}
+
+// authState returns auth state of the client creating it when needed.
+// c.mux must be held.
+func (c *Client) authState() *authState {
+	if c.auth == nil {
+		c.auth = &authState{warnBefore: DefaultTokenExpiryWarning}
+	}
+	return c.auth
+}
+
+// applyTier adjusts rate limit to tier of the token. c.mux must be held.
+func (c *Client) applyTier(info TokenInfo) {
+	if c.customLimiter {
+		return
+	}
+	if reqps, ok := c.tierLimits[info.Tier]; ok && reqps > 0 {
+		c.limiter = NewTokenBucket(float64(reqps), 1)
+	}
+}
+
+// authToken returns token to be used for the request,
+// empty string is returned when client is not authenticated.
+func (c *Client) authToken(ctx context.Context) (string, error) {
+	c.mux.RLock()
+	a := c.auth
+	c.mux.RUnlock()
+	if a == nil {
+		return "", nil
+	}
+
+	token, info, refreshed, warn, err := a.get(ctx, c.logTokenExpiry)
+	if err != nil {
+		return "", err
+	}
+	if refreshed {
+		c.mux.Lock()
+		c.applyTier(info)
+		c.mux.Unlock()
+	}
+	if warn != nil {
+		warn(info)
+	}
+	return token, nil
+}
+
+// authRejected drops token rejected by server so that
+// it is requested again from TokenProvider.
+func (c *Client) authRejected(token string) {
+	c.mux.RLock()
+	a := c.auth
+	c.mux.RUnlock()
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.provider != nil && a.token == token {
+		a.set("", TokenInfo{})
+	}
+}
+
+// get returns current token refreshing it from provider when needed.
+// Provider is called without holding the lock by single request,
+// other requests wait for the result or until their ctx is done.
+func (a *authState) get(ctx context.Context, defaultWarn func(TokenInfo)) (
+	token string, info TokenInfo, refreshed bool, warn func(TokenInfo), err error,
+) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for a.provider != nil && (len(a.token) == 0 || a.expires(tokenRefreshMargin)) {
+		if r := a.refresh; r != nil {
+			a.mu.Unlock()
+			select {
+			case <-r.done:
+			case <-ctx.Done():
+				a.mu.Lock()
+				return "", TokenInfo{}, false, nil, ctx.Err()
+			}
+			a.mu.Lock()
+			// refresh aborted by ctx of other request is retried.
+			if r.err != nil && !r.aborted {
+				return "", TokenInfo{}, false, nil, r.err
+			}
+			if r.err == nil && len(a.token) > 0 {
+				break
+			}
+			continue
+		}
+
+		r := &tokenRefresh{done: make(chan struct{})}
+		a.refresh = r
+		provider, gen := a.provider, a.gen
+		a.mu.Unlock()
+		token, info, r.err = refreshToken(ctx, provider)
+		r.aborted = ctx.Err() != nil
+		a.mu.Lock()
+		a.refresh = nil
+		close(r.done)
+		if r.err != nil {
+			return "", TokenInfo{}, false, nil, r.err
+		}
+		// token or provider was replaced during the refresh.
+		if gen != a.gen {
+			continue
+		}
+		a.set(token, info)
+		refreshed = true
+		break
+	}
+
+	if !a.warned && a.expires(a.warnBefore) {
+		a.warned = true
+		warn = a.onExpiry
+		if warn == nil {
+			warn = defaultWarn
+		}
+	}
+	return a.token, a.info, refreshed, warn, nil
+}
+
+// refreshToken obtains and parses token from provider.
+func refreshToken(ctx context.Context, provider TokenProvider) (string, TokenInfo, error) {
+	token, err := provider(ctx)
+	if err != nil {
+		return "", TokenInfo{}, &tokenProviderError{err: err}
+	}
+	info, err := parseToken(token)
+	if err != nil {
+		return "", TokenInfo{}, err
+	}
+	return token, info, nil
+}
+
+// Error implements error interface.
+func (e *tokenProviderError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrAuthToken.Error(), e.err.Error())
+}
+
+// Unwrap returns error of the provider.
+func (e *tokenProviderError) Unwrap() error {
+	return e.err
+}
+
+// Is reports whether target is ErrAuthToken.
+func (e *tokenProviderError) Is(target error) bool {
+	return target == ErrAuthToken
+}
+
+func (a *authState) set(token string, info TokenInfo) {
+	a.token = token
+	a.info = info
+	a.warned = false
+}
+
+// expires reports whether token expires within d.
+func (a *authState) expires(d time.Duration) bool {
+	return len(a.token) > 0 && !a.info.ExpiresAt.IsZero() && time.Until(a.info.ExpiresAt) < d
+}
+
+// logTokenExpiry logs expiry warning with logger of the client,
+// nothing is logged when Logging option was not used.
+func (c *Client) logTokenExpiry(info TokenInfo) {
+	c.mux.RLock()
+	logger := c.logger
+	c.mux.RUnlock()
+	if logger != nil {
+		logger.WarnContext(context.Background(), "koios: auth token expires soon",
+			"tier", info.Tier, "expires_at", info.ExpiresAt)
+	}
+}
+
+// parseToken decodes claims of JWT token, signature is not verified.
+func parseToken(token string) (TokenInfo, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return TokenInfo{}, fmt.Errorf("%w: token is not JWT", ErrAuthToken)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return TokenInfo{}, fmt.Errorf("%w: %s", ErrAuthToken, err.Error())
+	}
+	claims := tokenClaims{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return TokenInfo{}, fmt.Errorf("%w: %s", ErrAuthToken, err.Error())
+	}
+
+	info := TokenInfo{
+		Address:   claims.Addr,
+		ProjectID: claims.ProjID,
+	}
+	if len(claims.Tier) > 0 {
+		tier, err := strconv.Atoi(claims.Tier.String())
+		if err != nil {
+			return TokenInfo{}, fmt.Errorf("%w: invalid tier %q", ErrAuthToken, claims.Tier)
+		}
+		info.Tier = tier
+	}
+	if len(claims.Exp) > 0 {
+		exp, err := claims.Exp.Float64()
+		if err != nil {
+			return TokenInfo{}, fmt.Errorf("%w: invalid exp %q", ErrAuthToken, claims.Exp)
+		}
+		if exp > 0 {
+			info.ExpiresAt = time.Unix(int64(exp), 0).UTC()
+		}
+	}
+	return info, nil
+}
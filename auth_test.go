@@ -0,0 +1,311 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/howijd/koios-rest-go-client"
+)
+
+func newToken(tier int, exp time.Time) string {
+	enc := base64.RawURLEncoding
+	header := enc.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload := enc.EncodeToString([]byte(fmt.Sprintf(
+		`{"addr":"stake1u8yxtugdv63wxafy9d00nuz6hjyyp4qnggvc9a3vxh8yl0ckml2uz","exp":%d,"tier":%d,"projID":"test"}`,
+		exp.Unix(), tier)))
+	return header + "." + payload + ".signature"
+}
+
+func authHandler(mu *sync.Mutex, seen *[]string, status func(token string) int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		mu.Lock()
+		*seen = append(*seen, auth)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if code := status(auth); code != http.StatusOK {
+			w.WriteHeader(code)
+			_, _ = w.Write([]byte(`{"message":"unauthorized"}`))
+			return
+		}
+		_, _ = w.Write([]byte("[]"))
+	})
+}
+
+func TestAuth(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		seen []string
+	)
+	exp := time.Now().Add(30 * 24 * time.Hour).Truncate(time.Second).UTC()
+	token := newToken(2, exp)
+	api := newTestClient(t, authHandler(&mu, &seen, func(string) int {
+		return http.StatusOK
+	}), koios.Auth(token))
+
+	info, ok := api.TokenInfo()
+	assert.True(t, ok)
+	assert.Equal(t, 2, info.Tier)
+	assert.Equal(t, exp, info.ExpiresAt)
+	assert.Equal(t, "test", info.ProjectID)
+
+	_, err := api.GetAccountList(context.Background())
+	assert.NoError(t, err)
+	_, err = api.GetAccountList(context.Background(), koios.WithHeader("Authorization", "Bearer other"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Bearer " + token, "Bearer other"}, seen)
+
+	_, err = koios.New(koios.Auth("not-a-token"))
+	assert.ErrorIs(t, err, koios.ErrAuthToken)
+	_, err = koios.New(koios.AuthProvider(nil))
+	assert.ErrorIs(t, err, koios.ErrAuthProviderNil)
+
+	api, err = koios.New()
+	assert.NoError(t, err)
+	_, ok = api.TokenInfo()
+	assert.False(t, ok)
+}
+
+func TestAuthProvider(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		seen   []string
+		issued []string
+	)
+	provider := func(ctx context.Context) (string, error) {
+		// second token expires within refresh margin.
+		exp := time.Now().Add(time.Hour)
+		if len(issued) == 1 {
+			exp = time.Now().Add(30 * time.Second)
+		}
+		token := newToken(len(issued)+1, exp)
+		issued = append(issued, token)
+		return token, nil
+	}
+	api := newTestClient(t, authHandler(&mu, &seen, func(auth string) int {
+		if auth == "Bearer "+issued[0] && len(seen) > 1 {
+			return http.StatusUnauthorized
+		}
+		return http.StatusOK
+	}), koios.AuthProvider(provider), koios.AuthExpiryWarning(0, func(koios.TokenInfo) {}))
+
+	_, ok := api.TokenInfo()
+	assert.False(t, ok)
+
+	// first token is fetched on first request.
+	_, err := api.GetAccountList(context.Background())
+	assert.NoError(t, err)
+	// first token is rejected.
+	_, err = api.GetAccountList(context.Background())
+	assert.Error(t, err)
+	// second token is expiring so third is fetched after it is used.
+	_, err = api.GetAccountList(context.Background())
+	assert.NoError(t, err)
+	_, err = api.GetAccountList(context.Background())
+	assert.NoError(t, err)
+
+	assert.Len(t, issued, 3)
+	assert.Equal(t, []string{
+		"Bearer " + issued[0],
+		"Bearer " + issued[0],
+		"Bearer " + issued[1],
+		"Bearer " + issued[2],
+	}, seen)
+	info, ok := api.TokenInfo()
+	assert.True(t, ok)
+	assert.Equal(t, 3, info.Tier)
+
+	errVault := errors.New("vault unavailable")
+	assert.NoError(t, koios.AuthProvider(func(ctx context.Context) (string, error) {
+		return "", fmt.Errorf("token: %w", errVault)
+	})(api))
+	_, err = api.GetAccountList(context.Background())
+	assert.ErrorIs(t, err, koios.ErrAuthToken)
+	// error chain of the provider is preserved.
+	assert.ErrorIs(t, err, errVault)
+	assert.Contains(t, err.Error(), "vault unavailable")
+	assert.Len(t, seen, 4)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	assert.NoError(t, koios.AuthProvider(func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})(api))
+	_, err = api.GetAccountList(ctx)
+	assert.ErrorIs(t, err, koios.ErrAuthToken)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestAuthProviderSingleFlight(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		seen    []string
+		calls   int32
+		release = make(chan struct{})
+	)
+	provider := func(ctx context.Context) (string, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return newToken(1, time.Now().Add(time.Hour)), nil
+	}
+	api := newTestClient(t, authHandler(&mu, &seen, func(string) int {
+		return http.StatusOK
+	}), koios.AuthProvider(provider), koios.AuthExpiryWarning(0, func(koios.TokenInfo) {}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := api.GetAccountList(context.Background())
+			assert.NoError(t, err)
+		}()
+	}
+	for atomic.LoadInt32(&calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// other callers are not blocked by the refresh in progress.
+	_, ok := api.TokenInfo()
+	assert.False(t, ok)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := api.GetAccountList(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Len(t, seen, 10)
+}
+
+func TestAuthTierLimits(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		seen []string
+	)
+	ts := httptest.NewServer(authHandler(&mu, &seen, func(string) int {
+		return http.StatusOK
+	}))
+	defer ts.Close()
+	u, err := url.Parse(ts.URL)
+	assert.NoError(t, err)
+	port, err := strconv.ParseUint(u.Port(), 10, 16)
+	assert.NoError(t, err)
+
+	newClient := func(opts ...koios.Option) *koios.Client {
+		api, err := koios.New(append([]koios.Option{
+			koios.Schema(u.Scheme),
+			koios.Host(u.Hostname()),
+			koios.Port(uint16(port)),
+			koios.AuthExpiryWarning(0, func(koios.TokenInfo) {}),
+		}, opts...)...)
+		assert.NoError(t, err)
+		return api
+	}
+	elapsed := func(api *koios.Client) time.Duration {
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			_, err := api.GetAccountList(context.Background())
+			assert.NoError(t, err)
+		}
+		return time.Since(start)
+	}
+	token := newToken(2, time.Now().Add(time.Hour))
+
+	// default rate limit is kept without tier limits.
+	api := newClient(koios.Auth(token))
+	assert.GreaterOrEqual(t, elapsed(api), 350*time.Millisecond)
+
+	limits := map[int]uint8{2: 100}
+	api = newClient(koios.AuthTierLimits(limits), koios.Auth(token))
+	// limits are copied by the option.
+	limits[2] = 1
+	assert.Less(t, elapsed(api), 300*time.Millisecond)
+
+	// tiers missing in limits keep the rate limit.
+	api = newClient(koios.AuthTierLimits(map[int]uint8{1: 100}), koios.Auth(token))
+	assert.GreaterOrEqual(t, elapsed(api), 350*time.Millisecond)
+}
+
+func TestAuthExpiryWarning(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		seen   []string
+		warned []koios.TokenInfo
+	)
+	api := newTestClient(t, authHandler(&mu, &seen, func(string) int {
+		return http.StatusOK
+	}),
+		koios.Auth(newToken(1, time.Now().Add(2*time.Hour))),
+		koios.AuthExpiryWarning(24*time.Hour, func(info koios.TokenInfo) {
+			warned = append(warned, info)
+		}),
+	)
+
+	for i := 0; i < 3; i++ {
+		_, err := api.GetAccountList(context.Background())
+		assert.NoError(t, err)
+	}
+	if assert.Len(t, warned, 1) {
+		assert.Equal(t, 1, warned[0].Tier)
+	}
+
+	// new token resets the warning.
+	assert.NoError(t, koios.Auth(newToken(1, time.Now().Add(time.Hour)))(api))
+	_, err := api.GetAccountList(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, warned, 2)
+
+	// by default warning is logged only with logger of the client.
+	var stdlog bytes.Buffer
+	log.SetOutput(&stdlog)
+	defer log.SetOutput(os.Stderr)
+	logger := &testLogger{}
+	handler := authHandler(&mu, &seen, func(string) int { return http.StatusOK })
+	token := newToken(2, time.Now().Add(time.Hour))
+	for _, api := range []*koios.Client{
+		newTestClient(t, handler, koios.Auth(token)),
+		newTestClient(t, handler, koios.Auth(token), koios.Logging(logger)),
+	} {
+		_, err = api.GetAccountList(context.Background())
+		assert.NoError(t, err)
+	}
+	assert.Empty(t, stdlog.String())
+	if records := logger.find("koios: auth token expires soon"); assert.Len(t, records, 1) {
+		assert.Equal(t, "warn", records[0].level)
+		assert.Equal(t, 2, records[0].attrs["tier"])
+	}
+}
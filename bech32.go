@@ -0,0 +1,285 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios
+
+import (
+	"errors"
+	"hash/crc32"
+	"math/big"
+	"strings"
+)
+
+const (
+	bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	base58Charset = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+)
+
+var (
+	errBech32Checksum = errors.New("invalid bech32 checksum")
+	errBech32Format   = errors.New("invalid bech32 string")
+	errBase58Format   = errors.New("invalid base58 string")
+	errByronFormat    = errors.New("invalid byron address")
+)
+
+// bech32Decode decodes bech32 string into human readable part and data.
+// Unlike BIP-173 length of the string is not limited to 90 characters
+// since Cardano addresses are longer.
+func bech32Decode(s string) (hrp string, data []byte, err error) {
+	lower, upper := strings.ToLower(s), strings.ToUpper(s)
+	if s != lower && s != upper {
+		return "", nil, errBech32Format
+	}
+	s = lower
+	sep := strings.LastIndexByte(s, '1')
+	if sep < 1 || sep+7 > len(s) {
+		return "", nil, errBech32Format
+	}
+	hrp = s[:sep]
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, errBech32Format
+		}
+	}
+	values := make([]byte, 0, len(s)-sep-1)
+	for i := sep + 1; i < len(s); i++ {
+		v := strings.IndexByte(bech32Charset, s[i])
+		if v < 0 {
+			return "", nil, errBech32Format
+		}
+		values = append(values, byte(v))
+	}
+	if bech32Polymod(append(bech32ExpandHRP(hrp), values...)) != 1 {
+		return "", nil, errBech32Checksum
+	}
+	data, err = convertBits(values[:len(values)-6], 5, 8, false)
+	return hrp, data, err
+}
+
+// bech32Encode encodes data as bech32 string with human readable part hrp.
+func bech32Encode(hrp string, data []byte) string {
+	// regrouping of bytes with padding can not fail.
+	values, _ := convertBits(data, 8, 5, true)
+	poly := bech32Polymod(append(append(bech32ExpandHRP(hrp), values...), 0, 0, 0, 0, 0, 0)) ^ 1
+	for i := 0; i < 6; i++ {
+		values = append(values, byte(poly>>uint(5*(5-i)))&31)
+	}
+	var b strings.Builder
+	b.Grow(len(hrp) + 1 + len(values))
+	b.WriteString(hrp)
+	b.WriteByte('1')
+	for _, v := range values {
+		b.WriteByte(bech32Charset[v])
+	}
+	return b.String()
+}
+
+func bech32Polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32ExpandHRP(hrp string) []byte {
+	out := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]>>5)
+	}
+	out = append(out, 0)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]&31)
+	}
+	return out
+}
+
+// convertBits regroups data from groups of from bits to groups of to bits.
+func convertBits(data []byte, from, to uint, pad bool) ([]byte, error) {
+	var (
+		acc  uint32
+		bits uint
+		out  = make([]byte, 0, len(data)*int(from)/int(to)+1)
+		max  = uint32(1)<<to - 1
+	)
+	for _, v := range data {
+		if uint32(v)>>from != 0 {
+			return nil, errBech32Format
+		}
+		acc = acc<<from | uint32(v)
+		bits += from
+		for bits >= to {
+			bits -= to
+			out = append(out, byte(acc>>bits&max))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			out = append(out, byte(acc<<(to-bits)&max))
+		}
+	} else if bits >= from || acc<<(to-bits)&max != 0 {
+		return nil, errBech32Format
+	}
+	return out, nil
+}
+
+// base58Decode decodes base58 string using bitcoin alphabet.
+func base58Decode(s string) ([]byte, error) {
+	if len(s) == 0 {
+		return nil, errBase58Format
+	}
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for i := 0; i < len(s); i++ {
+		v := strings.IndexByte(base58Charset, s[i])
+		if v < 0 {
+			return nil, errBase58Format
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(v)))
+	}
+	zeros := 0
+	for zeros < len(s) && s[zeros] == base58Charset[0] {
+		zeros++
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
+// base58Encode encodes data as base58 string using bitcoin alphabet.
+func base58Encode(data []byte) string {
+	var (
+		n     = new(big.Int).SetBytes(data)
+		radix = big.NewInt(58)
+		mod   = new(big.Int)
+		out   []byte
+	)
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, base58Charset[mod.Int64()])
+	}
+	for i := 0; i < len(data) && data[i] == 0; i++ {
+		out = append(out, base58Charset[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+// decodeByronAddress checks CBOR structure and CRC of base58 decoded
+// Byron address and returns network magic when address has one.
+//
+// Byron address is CBOR array [#6.24(bytes .cbor payload), crc32]
+// where payload is array [root hash, attributes, address type].
+func decodeByronAddress(raw []byte) (magic uint32, hasMagic bool, err error) {
+	r := &cborReader{b: raw}
+	if n, ok := r.head(4); !ok || n != 2 {
+		return 0, false, errByronFormat
+	}
+	if tag, ok := r.head(6); !ok || tag != 24 {
+		return 0, false, errByronFormat
+	}
+	payload, ok := r.bytes()
+	if !ok {
+		return 0, false, errByronFormat
+	}
+	crc, ok := r.head(0)
+	if !ok || len(r.b) != 0 || uint64(crc32.ChecksumIEEE(payload)) != crc {
+		return 0, false, errByronFormat
+	}
+
+	p := &cborReader{b: payload}
+	if n, ok := p.head(4); !ok || n != 3 {
+		return 0, false, errByronFormat
+	}
+	if root, ok := p.bytes(); !ok || len(root) != 28 {
+		return 0, false, errByronFormat
+	}
+	attrs, ok := p.head(5)
+	if !ok {
+		return 0, false, errByronFormat
+	}
+	for i := uint64(0); i < attrs; i++ {
+		key, ok := p.head(0)
+		if !ok {
+			return 0, false, errByronFormat
+		}
+		value, ok := p.bytes()
+		if !ok {
+			return 0, false, errByronFormat
+		}
+		// attribute 2 holds CBOR encoded protocol magic of test networks.
+		if key == 2 {
+			m, ok := (&cborReader{b: value}).head(0)
+			if !ok || m > 1<<32-1 {
+				return 0, false, errByronFormat
+			}
+			magic, hasMagic = uint32(m), true
+		}
+	}
+	if _, ok := p.head(0); !ok || len(p.b) != 0 {
+		return 0, false, errByronFormat
+	}
+	return magic, hasMagic, nil
+}
+
+// cborReader reads minimal subset of CBOR needed to decode Byron addresses.
+type cborReader struct {
+	b []byte
+}
+
+// head reads head of data item of major type and returns its argument.
+func (r *cborReader) head(major byte) (uint64, bool) {
+	if len(r.b) == 0 || r.b[0]>>5 != major {
+		return 0, false
+	}
+	info := r.b[0] & 0x1f
+	r.b = r.b[1:]
+	if info < 24 {
+		return uint64(info), true
+	}
+	if info > 27 {
+		return 0, false
+	}
+	size := 1 << (info - 24)
+	if len(r.b) < size {
+		return 0, false
+	}
+	var v uint64
+	for _, c := range r.b[:size] {
+		v = v<<8 | uint64(c)
+	}
+	r.b = r.b[size:]
+	return v, true
+}
+
+// bytes reads definite length byte string.
+func (r *cborReader) bytes() ([]byte, bool) {
+	n, ok := r.head(2)
+	if !ok || n > uint64(len(r.b)) {
+		return nil, false
+	}
+	v := r.b[:n]
+	r.b = r.b[n:]
+	return v, true
+}
@@ -18,8 +18,6 @@ package koios
 
 import (
 	"context"
-	"encoding/json"
-	"net/http"
 	"net/url"
 )
 
@@ -87,77 +85,86 @@ type (
 )
 
 // GetBlocks returns summarised details about all blocks (paginated - latest first).
-func (c *Client) GetBlocks(ctx context.Context) (res *BlocksResponse, err error) {
+// Use BlocksIterator to walk through all pages.
+func (c *Client) GetBlocks(ctx context.Context, opts ...CallOption) (res *BlocksResponse, err error) {
+	return c.getBlocks(ctx, nil, opts...)
+}
+
+// BlocksIterator returns iterator over all blocks (latest first).
+func (c *Client) BlocksIterator(ctx context.Context, opts ...CallOption) *Iterator[Block] {
+	return newIterator(ctx, c, func(ctx context.Context, query url.Values) ([]Block, *Response, error) {
+		res, err := c.getBlocks(ctx, query, opts...)
+		return res.Data, &res.Response, err
+	})
+}
+
+func (c *Client) getBlocks(ctx context.Context, query url.Values, opts ...CallOption) (res *BlocksResponse, err error) {
 	res = &BlocksResponse{}
-	rsp, err := c.request(ctx, &res.Response, "GET", "/blocks", nil, nil, nil)
+	rsp, err := c.request(ctx, &res.Response, "GET", "/blocks", nil, query, nil, opts...)
 	if err != nil {
-		res.applyError(nil, err)
 		return
 	}
 
-	body, err := readResponseBody(rsp)
-	if err != nil {
-		res.applyError(body, err)
-		return
-	}
-	if err = json.Unmarshal(body, &res.Data); err != nil {
-		res.applyError(body, err)
-		return
-	}
-	res.ready()
+	err = readAndUnmarshalResponse(rsp, &res.Response, &res.Data)
 	return
 }
 
 // GetBlockInfo returns detailed information about a specific block.
-func (c *Client) GetBlockInfo(ctx context.Context, hash BlockHash) (res *BlockInfoResponse, err error) {
+func (c *Client) GetBlockInfo(
+	ctx context.Context,
+	hash BlockHash,
+	opts ...CallOption,
+) (res *BlockInfoResponse, err error) {
 	res = &BlockInfoResponse{}
 	params := url.Values{}
 	params.Set("_block_hash", string(hash))
 
-	rsp, err := c.request(ctx, &res.Response, "GET", "/block_info", nil, params, nil)
-	if err != nil {
+	rc := c.responseCache(opts)
+	if rc != nil && rc.get(rc.key("block_info", string(hash)), &res.Data) {
+		c.applyCached(ctx, rc, &res.Response, "GET", "block_info", params)
 		return
 	}
-	body, err := readResponseBody(rsp)
+
+	rsp, err := c.request(ctx, &res.Response, "GET", "/block_info", nil, params, nil, opts...)
 	if err != nil {
-		res.applyError(nil, err)
 		return
 	}
-
 	blockpl := []Block{}
 
-	if err = json.Unmarshal(body, &blockpl); err != nil {
-		res.applyError(body, err)
-		return
-	}
-
-	if rsp.StatusCode != http.StatusOK {
-		res.applyError(body, err)
+	if err = readAndUnmarshalResponse(rsp, &res.Response, &blockpl); err != nil {
 		return
 	}
 	if len(blockpl) == 1 {
 		res.Data = &blockpl[0]
+		if rc != nil {
+			if tip, _ := c.cachedTip(ctx, rc); rc.isFinal(tip, res.Data.Height) {
+				rc.set(rc.key("block_info", string(hash)), res.Data)
+			}
+		}
 	}
-	res.ready()
-	return res, nil
+	return
 }
 
 // GetBlockTxHashes returns a list of all transactions hashes
 // included in a provided block.
-func (c *Client) GetBlockTxHashes(ctx context.Context, hash BlockHash) (res *BlockTxsHashesResponse, err error) {
+func (c *Client) GetBlockTxHashes(
+	ctx context.Context,
+	hash BlockHash,
+	opts ...CallOption,
+) (res *BlockTxsHashesResponse, err error) {
 	res = &BlockTxsHashesResponse{}
 	params := url.Values{}
 	params.Set("_block_hash", string(hash))
 
-	rsp, err := c.request(ctx, &res.Response, "GET", "/block_txs", nil, params, nil)
-	if err != nil {
-		res.applyError(nil, err)
-		return nil, err
+	// transactions of the block identified by hash never change.
+	rc := c.responseCache(opts)
+	if rc != nil && rc.get(rc.key("block_txs", string(hash)), &res.Data) {
+		c.applyCached(ctx, rc, &res.Response, "GET", "block_txs", params)
+		return
 	}
 
-	body, err := readResponseBody(rsp)
+	rsp, err := c.request(ctx, &res.Response, "GET", "/block_txs", nil, params, nil, opts...)
 	if err != nil {
-		res.applyError(body, err)
 		return
 	}
 
@@ -165,20 +172,16 @@ func (c *Client) GetBlockTxHashes(ctx context.Context, hash BlockHash) (res *Blo
 		Hash TxHash `json:"tx_hash"`
 	}{}
 
-	if err = json.Unmarshal(body, &blockTxs); err != nil {
-		res.applyError(body, err)
-		return
-	}
-
-	if rsp.StatusCode != http.StatusOK {
-		res.applyError(body, err)
+	if err = readAndUnmarshalResponse(rsp, &res.Response, &blockTxs); err != nil {
 		return
 	}
 	if len(blockTxs) > 0 {
 		for _, tx := range blockTxs {
 			res.Data = append(res.Data, tx.Hash)
 		}
+		if rc != nil {
+			rc.set(rc.key("block_txs", string(hash)), res.Data)
+		}
 	}
-	res.ready()
-	return res, nil
+	return
 }
@@ -0,0 +1,290 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// States of the circuit breaker.
+//
+// BreakerClosed  : requests are sent to the host.
+// BreakerOpen    : requests fail fast with *CircuitOpenError.
+// BreakerHalfOpen: limited number of probe requests is sent to the host
+// to find out whether it recovered.
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+type (
+	// BreakerState is state of the circuit breaker of the host.
+	BreakerState uint8
+
+	// BreakerPolicy configures circuit breaker.
+	BreakerPolicy struct {
+		// FailureThreshold is number of consecutive failed requests
+		// which opens the circuit.
+		FailureThreshold int
+
+		// CoolDown is duration for which circuit stays open
+		// before probe requests are allowed.
+		CoolDown time.Duration
+
+		// HalfOpenRequests is number of probe requests allowed
+		// in half-open state, 0 is treated as 1. Circuit is closed
+		// when all of them succeed and opened again when any fails.
+		HalfOpenRequests int
+
+		// OnStateChange is called on every state transition
+		// of the circuit of the host.
+		OnStateChange func(host string, from, to BreakerState)
+	}
+
+	// CircuitOpenError is returned when request is rejected
+	// because circuit of the host is open. It matches
+	// ErrCircuitOpen and ErrServerUnavailable with errors.Is.
+	CircuitOpenError struct {
+		// Host which circuit is open.
+		Host string
+
+		// RetryAt is time when circuit becomes half-open.
+		RetryAt time.Time
+	}
+
+	// circuitBreakers tracks circuits of hosts.
+	circuitBreakers struct {
+		mux      sync.Mutex
+		client   *Client
+		policy   BreakerPolicy
+		circuits map[string]*circuit
+	}
+
+	circuit struct {
+		state     BreakerState
+		failures  int
+		openUntil time.Time
+		probes    int
+		successes int
+	}
+)
+
+// DefaultBreakerPolicy returns BreakerPolicy opening circuit after
+// 5 consecutive failures for 30 seconds.
+func DefaultBreakerPolicy() BreakerPolicy {
+	return BreakerPolicy{
+		FailureThreshold: 5,
+		CoolDown:         30 * time.Second,
+		HalfOpenRequests: 1,
+	}
+}
+
+// CircuitBreaker enables circuit breaker per host. Connection errors and
+// 5xx responses are counted as failures, when FailureThreshold consecutive
+// requests to the host fail its circuit is opened and requests to the host
+// fail fast with *CircuitOpenError until CoolDown passes. When Instances
+// are configured requests are sent to other instances instead.
+func CircuitBreaker(policy BreakerPolicy) Option {
+	return func(c *Client) error {
+		if policy.FailureThreshold < 1 {
+			return ErrBreakerThreshold
+		}
+		if policy.CoolDown <= 0 {
+			return ErrBreakerCoolDown
+		}
+		if policy.HalfOpenRequests < 1 {
+			policy.HalfOpenRequests = 1
+		}
+		c.mux.Lock()
+		defer c.mux.Unlock()
+		c.breakers = &circuitBreakers{
+			client:   c,
+			policy:   policy,
+			circuits: make(map[string]*circuit),
+		}
+		return nil
+	}
+}
+
+// CircuitStates returns state of circuit of hosts which
+// received requests, nil is returned when circuit breaker
+// is not enabled.
+func (c *Client) CircuitStates() map[string]BreakerState {
+	c.mux.RLock()
+	breakers := c.breakers
+	c.mux.RUnlock()
+	if breakers == nil {
+		return nil
+	}
+	breakers.mux.Lock()
+	defer breakers.mux.Unlock()
+	states := make(map[string]BreakerState, len(breakers.circuits))
+	for host, cb := range breakers.circuits {
+		states[host] = cb.currentState(time.Now())
+	}
+	return states
+}
+
+// String returns name of the state.
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// Error implements error interface.
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s: %s until %s", ErrCircuitOpen.Error(), e.Host, e.RetryAt.Format(time.RFC3339))
+}
+
+// Is reports whether target is ErrCircuitOpen or ErrServerUnavailable.
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen || target == ErrServerUnavailable
+}
+
+// allow reports whether request to host can be sent,
+// *CircuitOpenError is returned when circuit is open.
+func (b *circuitBreakers) allow(host string) error {
+	b.mux.Lock()
+	cb, ok := b.circuits[host]
+	if !ok {
+		cb = &circuit{}
+		b.circuits[host] = cb
+	}
+	now := time.Now()
+	from := cb.state
+	if cb.state == BreakerOpen && !now.Before(cb.openUntil) {
+		cb.state = BreakerHalfOpen
+		cb.probes, cb.successes = 0, 0
+	}
+	var err error
+	switch cb.state {
+	case BreakerOpen:
+		err = &CircuitOpenError{Host: host, RetryAt: cb.openUntil}
+	case BreakerHalfOpen:
+		if cb.probes >= b.policy.HalfOpenRequests {
+			err = &CircuitOpenError{Host: host, RetryAt: now.Add(time.Second)}
+		} else {
+			cb.probes++
+		}
+	}
+	to := cb.state
+	b.mux.Unlock()
+	b.changed(host, from, to)
+	return err
+}
+
+// report records result of request allowed by allow. Requests which were
+// not sent e.g. because auth token or rate limit wait failed and requests
+// which were not completed because context was canceled are reported as ignored.
+func (b *circuitBreakers) report(host string, failed, ignored bool) {
+	b.mux.Lock()
+	cb, ok := b.circuits[host]
+	if !ok {
+		b.mux.Unlock()
+		return
+	}
+	from := cb.state
+	switch cb.state {
+	case BreakerClosed:
+		if ignored {
+			break
+		}
+		if !failed {
+			cb.failures = 0
+			break
+		}
+		cb.failures++
+		if cb.failures >= b.policy.FailureThreshold {
+			cb.open(b.policy.CoolDown)
+		}
+	case BreakerHalfOpen:
+		if ignored {
+			cb.probes--
+			break
+		}
+		if failed {
+			cb.open(b.policy.CoolDown)
+			break
+		}
+		cb.successes++
+		if cb.successes >= b.policy.HalfOpenRequests {
+			cb.state = BreakerClosed
+			cb.failures = 0
+		}
+	case BreakerOpen:
+		// result of request sent before circuit was opened.
+	}
+	to := cb.state
+	b.mux.Unlock()
+	b.changed(host, from, to)
+}
+
+func (b *circuitBreakers) changed(host string, from, to BreakerState) {
+	if from == to {
+		return
+	}
+	b.client.mux.RLock()
+	logger := b.client.logger
+	b.client.mux.RUnlock()
+	if logger != nil {
+		args := []any{"host", host, "from", from.String(), "to", to.String()}
+		if to == BreakerOpen {
+			logger.WarnContext(context.Background(), "koios: circuit opened", args...)
+		} else {
+			logger.InfoContext(context.Background(), "koios: circuit state changed", args...)
+		}
+	}
+	if b.policy.OnStateChange != nil {
+		b.policy.OnStateChange(host, from, to)
+	}
+}
+
+func (cb *circuit) open(cooldown time.Duration) {
+	cb.state = BreakerOpen
+	cb.openUntil = time.Now().Add(cooldown)
+	cb.probes, cb.successes = 0, 0
+}
+
+// currentState returns state taking passed cool down into account.
+func (cb *circuit) currentState(now time.Time) BreakerState {
+	if cb.state == BreakerOpen && !now.Before(cb.openUntil) {
+		return BreakerHalfOpen
+	}
+	return cb.state
+}
+
+// isHostFailure reports whether response indicates that host is down.
+// Only results of requests which reached the transport are reported,
+// so err is transport error.
+func isHostFailure(rsp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return rsp.StatusCode >= 500
+}
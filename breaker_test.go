@@ -0,0 +1,206 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/howijd/koios-rest-go-client"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var (
+		hits    int32
+		healthy int32
+		mu      sync.Mutex
+		changes []string
+	)
+	policy := koios.BreakerPolicy{
+		FailureThreshold: 2,
+		CoolDown:         200 * time.Millisecond,
+		OnStateChange: func(host string, from, to koios.BreakerState) {
+			mu.Lock()
+			defer mu.Unlock()
+			changes = append(changes, from.String()+"->"+to.String())
+		},
+	}
+	api := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Content-Type", "application/json")
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+		_, _ = w.Write([]byte("[]"))
+	}), koios.CircuitBreaker(policy))
+	u, err := url.Parse(api.BaseURL())
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err := api.GetAccountList(context.Background())
+		assert.ErrorIs(t, err, koios.ErrServerUnavailable)
+		assert.False(t, errors.Is(err, koios.ErrCircuitOpen))
+	}
+	assert.Equal(t, koios.BreakerOpen, api.CircuitStates()[u.Host])
+
+	// fails fast while circuit is open.
+	_, err = api.GetAccountList(context.Background())
+	assert.ErrorIs(t, err, koios.ErrCircuitOpen)
+	assert.ErrorIs(t, err, koios.ErrServerUnavailable)
+	var cerr *koios.CircuitOpenError
+	if assert.True(t, errors.As(err, &cerr)) {
+		assert.Equal(t, u.Host, cerr.Host)
+		assert.True(t, cerr.RetryAt.After(time.Now()))
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+
+	// failed probe opens circuit again.
+	waitCircuitState(t, api, u.Host, koios.BreakerHalfOpen)
+	_, err = api.GetAccountList(context.Background())
+	assert.ErrorIs(t, err, koios.ErrServerUnavailable)
+	_, err = api.GetAccountList(context.Background())
+	assert.ErrorIs(t, err, koios.ErrCircuitOpen)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&hits))
+
+	// successful probe closes circuit.
+	atomic.StoreInt32(&healthy, 1)
+	waitCircuitState(t, api, u.Host, koios.BreakerHalfOpen)
+	_, err = api.GetAccountList(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, koios.BreakerClosed, api.CircuitStates()[u.Host])
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{
+		"closed->open",
+		"open->half-open",
+		"half-open->open",
+		"open->half-open",
+		"half-open->closed",
+	}, changes)
+}
+
+func TestCircuitBreakerInstances(t *testing.T) {
+	failing := newTipServer(t, 10, http.StatusServiceUnavailable)
+	healthy := newTipServer(t, 12, http.StatusOK)
+	policy := koios.DefaultBreakerPolicy()
+	policy.FailureThreshold = 1
+
+	api, err := koios.New(
+		koios.RateLimit(255),
+		koios.CircuitBreaker(policy),
+		koios.Instances(koios.RoundRobin,
+			koios.Instance{URL: failing.URL + "/api/v0"},
+			koios.Instance{URL: healthy.URL + "/api/v0"},
+		),
+	)
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err := api.GetPoolList(context.Background())
+		assert.NoError(t, err)
+	}
+	states := api.CircuitStates()
+	assert.Equal(t, koios.BreakerOpen, states[failing.Listener.Addr().String()])
+	assert.Equal(t, koios.BreakerClosed, states[healthy.Listener.Addr().String()])
+
+	// requests fail fast when circuits of all instances are open.
+	other := newTipServer(t, 12, http.StatusServiceUnavailable)
+	api, err = koios.New(
+		koios.RateLimit(255),
+		koios.CircuitBreaker(policy),
+		koios.Instances(koios.RoundRobin,
+			koios.Instance{URL: failing.URL + "/api/v0"},
+			koios.Instance{URL: other.URL + "/api/v0"},
+		),
+	)
+	assert.NoError(t, err)
+	_, err = api.GetPoolList(context.Background())
+	assert.ErrorIs(t, err, koios.ErrServerUnavailable)
+	assert.False(t, errors.Is(err, koios.ErrCircuitOpen))
+	_, err = api.GetPoolList(context.Background())
+	assert.ErrorIs(t, err, koios.ErrCircuitOpen)
+}
+
+func TestCircuitBreakerLocalFailures(t *testing.T) {
+	var hits int32
+	policy := koios.DefaultBreakerPolicy()
+	policy.FailureThreshold = 1
+	api := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}),
+		koios.CircuitBreaker(policy),
+		koios.AuthProvider(func(ctx context.Context) (string, error) {
+			return "", errors.New("provider is down")
+		}),
+	)
+	u, err := url.Parse(api.BaseURL())
+	assert.NoError(t, err)
+
+	// failures before request is sent do not open the circuit.
+	for i := 0; i < 3; i++ {
+		_, err = api.GetAccountList(context.Background())
+		assert.ErrorIs(t, err, koios.ErrAuthToken)
+	}
+	assert.Equal(t, koios.BreakerClosed, api.CircuitStates()[u.Host])
+	assert.Equal(t, int32(0), atomic.LoadInt32(&hits))
+
+	assert.NoError(t, koios.Auth(newToken(1, time.Now().Add(time.Hour)))(api))
+	_, err = api.GetAccountList(context.Background())
+	assert.ErrorIs(t, err, koios.ErrServerUnavailable)
+	assert.Equal(t, koios.BreakerOpen, api.CircuitStates()[u.Host])
+
+	// payload of rejected request is released.
+	before := runtime.NumGoroutine()
+	for i := 0; i < 50; i++ {
+		_, err = api.GetTxsUTxOs(context.Background(), []koios.TxHash{"tx"})
+		assert.ErrorIs(t, err, koios.ErrCircuitOpen)
+	}
+	assertGoroutinesExit(t, before)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+}
+
+func TestCircuitBreakerOption(t *testing.T) {
+	_, err := koios.New(koios.CircuitBreaker(koios.BreakerPolicy{CoolDown: time.Second}))
+	assert.ErrorIs(t, err, koios.ErrBreakerThreshold)
+	_, err = koios.New(koios.CircuitBreaker(koios.BreakerPolicy{FailureThreshold: 1}))
+	assert.ErrorIs(t, err, koios.ErrBreakerCoolDown)
+
+	api, err := koios.New()
+	assert.NoError(t, err)
+	assert.Nil(t, api.CircuitStates())
+}
+
+// waitCircuitState waits until circuit of host reaches state.
+func waitCircuitState(t *testing.T, api *koios.Client, host string, state koios.BreakerState) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for api.CircuitStates()[host] != state && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	assert.Equal(t, state, api.CircuitStates()[host])
+}
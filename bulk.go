@@ -0,0 +1,228 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+type (
+	// ChunkError is error of single chunk of bulk request.
+	ChunkError struct {
+		// Offset is index of the first item of the chunk in requested items.
+		Offset int
+		// Len is number of items in the chunk.
+		Len int
+		// Err is error returned by the chunk request.
+		Err error
+	}
+
+	// BulkError is returned when one or more chunks of bulk request fail.
+	// Response data then contains results of successful chunks.
+	BulkError struct {
+		// Chunks are errors of failed chunks ordered by offset.
+		Chunks []*ChunkError
+	}
+
+	// chunkFetcher fetches results for single chunk of bulk request.
+	chunkFetcher[In, Out any] func(ctx context.Context, chunk []In) ([]Out, *Response, error)
+)
+
+// BulkChunking configures how bulk POST lookups e.g. GetTxsInfos,
+// GetPoolInfos, GetAddressTxs are split. Requested items are sent
+// in chunks of at most size items with up to concurrency chunks in flight,
+// results are merged preserving order of requested items.
+func BulkChunking(size, concurrency uint) Option {
+	return func(c *Client) error {
+		if size == 0 {
+			return ErrChunkSize
+		}
+		if concurrency == 0 {
+			return ErrChunkConcurrency
+		}
+		c.mux.Lock()
+		defer c.mux.Unlock()
+		c.chunkSize = int(size)
+		c.chunkConcurrency = int(concurrency)
+		return nil
+	}
+}
+
+// Error implements error interface.
+func (e *ChunkError) Error() string {
+	return fmt.Sprintf("chunk %d-%d: %s", e.Offset, e.Offset+e.Len-1, e.Err)
+}
+
+// Unwrap returns underlying error.
+func (e *ChunkError) Unwrap() error {
+	return e.Err
+}
+
+// Error implements error interface.
+func (e *BulkError) Error() string {
+	var parts []string
+	for _, chunk := range e.Chunks {
+		parts = append(parts, chunk.Error())
+	}
+	return fmt.Sprintf("%d chunk(s) failed: %s", len(e.Chunks), strings.Join(parts, "; "))
+}
+
+// Is reports whether any of the chunk errors matches target.
+func (e *BulkError) Is(target error) bool {
+	for _, chunk := range e.Chunks {
+		if errors.Is(chunk, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// bulk sends items in chunks using fetch and merges the results.
+// When key is not nil results are reordered to match order of the items.
+// Metadata of first chunk (or first failed chunk) is applied to res.
+func bulk[In comparable, Out any](
+	ctx context.Context,
+	c *Client,
+	res *Response,
+	items []In,
+	key func(Out) In,
+	fetch chunkFetcher[In, Out],
+) ([]Out, error) {
+	c.mux.RLock()
+	size, concurrency := c.chunkSize, c.chunkConcurrency
+	c.mux.RUnlock()
+
+	if len(items) <= size {
+		data, r, err := fetch(ctx, items)
+		if r != nil {
+			*res = *r
+		}
+		return data, err
+	}
+
+	type result struct {
+		data []Out
+		res  *Response
+		err  error
+	}
+	var (
+		chunks  = (len(items) + size - 1) / size
+		results = make([]result, chunks)
+		sem     = make(chan struct{}, concurrency)
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < chunks; i++ {
+		end := (i + 1) * size
+		if end > len(items) {
+			end = len(items)
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, chunk []In) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			data, r, err := fetch(ctx, chunk)
+			results[i] = result{data, r, err}
+		}(i, items[i*size:end])
+	}
+	wg.Wait()
+
+	var (
+		data    []Out
+		bulkErr = &BulkError{}
+	)
+	for i, r := range results {
+		if r.err != nil {
+			if len(bulkErr.Chunks) == 0 && r.res != nil {
+				*res = *r.res
+			}
+			offset := i * size
+			n := len(items) - offset
+			if n > size {
+				n = size
+			}
+			bulkErr.Chunks = append(bulkErr.Chunks, &ChunkError{
+				Offset: offset,
+				Len:    n,
+				Err:    r.err,
+			})
+			continue
+		}
+		if i == 0 && r.res != nil {
+			*res = *r.res
+		}
+		data = append(data, r.data...)
+	}
+
+	if key != nil {
+		data = orderBy(items, data, key)
+	}
+
+	if len(bulkErr.Chunks) > 0 {
+		res.Error = nil
+		return data, res.applyError(nil, bulkErr)
+	}
+	return data, nil
+}
+
+// postChunk returns chunkFetcher sending chunk as POST request
+// with payload encoded by pl.
+func postChunk[In, Out any](
+	c *Client,
+	path string,
+	pl func([]In) io.Reader,
+	opts []CallOption,
+) chunkFetcher[In, Out] {
+	// bulk requests should not delay other calls, see WithPriority.
+	opts = append([]CallOption{WithPriority(PriorityLow)}, opts...)
+	return func(ctx context.Context, chunk []In) ([]Out, *Response, error) {
+		res := &Response{}
+		rsp, err := c.request(ctx, res, "POST", path, pl(chunk), nil, nil, opts...)
+		if err != nil {
+			return nil, res, err
+		}
+		data := []Out{}
+		err = readAndUnmarshalResponse(rsp, res, &data)
+		return data, res, err
+	}
+}
+
+// orderBy orders data to match order of items.
+func orderBy[In comparable, Out any](items []In, data []Out, key func(Out) In) []Out {
+	byKey := make(map[In][]Out, len(data))
+	for _, d := range data {
+		k := key(d)
+		byKey[k] = append(byKey[k], d)
+	}
+	ordered := make([]Out, 0, len(data))
+	for _, item := range items {
+		ordered = append(ordered, byKey[item]...)
+		delete(byKey, item)
+	}
+	// append results not matching any of the items.
+	for _, d := range data {
+		if _, ok := byKey[key(d)]; ok {
+			ordered = append(ordered, d)
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,122 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/howijd/koios-rest-go-client"
+)
+
+func TestBulkChunking(t *testing.T) {
+	api := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var pl struct {
+			TxHashes []string `json:"_tx_hashes"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&pl)
+		w.Header().Set("Content-Type", "application/json")
+		// fail second chunk.
+		if pl.TxHashes[0] == "tx100" {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"message":"boom"}`)
+			return
+		}
+		// respond in reverse order.
+		var out []map[string]string
+		for i := len(pl.TxHashes) - 1; i >= 0; i-- {
+			out = append(out, map[string]string{"tx_hash": pl.TxHashes[i]})
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	}))
+
+	var txs []koios.TxHash
+	for i := 0; i < 250; i++ {
+		txs = append(txs, koios.TxHash(fmt.Sprintf("tx%d", i)))
+	}
+	res, err := api.GetTxsStatuses(context.Background(), txs)
+
+	var be *koios.BulkError
+	if assert.True(t, errors.As(err, &be)) {
+		assert.Len(t, be.Chunks, 1)
+		assert.Equal(t, 100, be.Chunks[0].Offset)
+	}
+	assert.True(t, errors.Is(err, koios.ErrServerUnavailable))
+	assert.Len(t, res.Data, 150)
+	assert.Equal(t, koios.TxHash("tx0"), res.Data[0].TxHash)
+	assert.Equal(t, koios.TxHash("tx200"), res.Data[100].TxHash)
+}
+
+func TestBulkAddressTxsOrder(t *testing.T) {
+	const total = 250
+	height := func(addr string) int {
+		i, _ := strconv.Atoi(strings.TrimPrefix(addr, "a"))
+		return (i * 97) % total
+	}
+	api := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var pl struct {
+			Addresses []string `json:"_addresses"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&pl)
+		// first chunk completes last.
+		if pl.Addresses[0] == "a0" {
+			time.Sleep(20 * time.Millisecond)
+		}
+		// each chunk is sorted by block height descending.
+		sort.Slice(pl.Addresses, func(i, j int) bool {
+			return height(pl.Addresses[i]) > height(pl.Addresses[j])
+		})
+		var out []map[string]interface{}
+		for _, addr := range pl.Addresses {
+			out = append(out, map[string]interface{}{"tx_hash": "tx" + addr, "block_height": height(addr)})
+		}
+		// transaction of multiple addresses.
+		out = append(out, map[string]interface{}{"tx_hash": "shared", "block_height": 0})
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(out)
+	}))
+
+	var addrs []koios.Address
+	for i := 0; i < total; i++ {
+		addrs = append(addrs, koios.Address(fmt.Sprint("a", i)))
+	}
+	res, err := api.GetAddressTxs(context.Background(), addrs, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, res.Data, total+1) {
+		for i, tx := range res.Data[:total] {
+			assert.Equal(t, total-1-i, height(strings.TrimPrefix(string(tx), "tx")))
+		}
+		assert.Equal(t, koios.TxHash("shared"), res.Data[total])
+	}
+}
+
+func TestBulkChunkingOption(t *testing.T) {
+	_, err := koios.New(koios.BulkChunking(0, 1))
+	assert.ErrorIs(t, err, koios.ErrChunkSize)
+	_, err = koios.New(koios.BulkChunking(1, 0))
+	assert.ErrorIs(t, err, koios.ErrChunkConcurrency)
+}
@@ -0,0 +1,345 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type (
+	// Cache is used by API client to cache responses of immutable chain data.
+	// Implementation must be safe for concurrent use.
+	Cache interface {
+		// Get returns cached value for key if present and not expired.
+		Get(key string) ([]byte, bool)
+
+		// Set stores value for key. ttl 0 means that value never expires.
+		Set(key string, value []byte, ttl time.Duration)
+	}
+
+	// CachePolicy defines when data returned by the API is considered
+	// immutable and therefore cached.
+	//
+	// Following endpoints are cached:
+	//  - /tx_info transactions with at least Confirmations confirmations.
+	//  - /block_info blocks with at least Confirmations confirmations.
+	//  - /block_txs transaction hashes of the block (by block hash).
+	//  - /epoch_params parameters of epochs older than current epoch.
+	//  - /totals totals of epochs older than current epoch.
+	//  - /genesis network genesis parameters.
+	CachePolicy struct {
+		// Confirmations is number of blocks after which transaction
+		// or block is considered final.
+		Confirmations uint64
+
+		// TTL of cached immutable data, 0 means no expiry.
+		TTL time.Duration
+
+		// TipTTL is how long the chain tip used to evaluate
+		// immutability of the data is cached.
+		TipTTL time.Duration
+	}
+
+	// LRUCache is in-memory Cache evicting least recently used
+	// entries when size limit is reached.
+	LRUCache struct {
+		mux     sync.Mutex
+		size    int
+		ll      *list.List
+		entries map[string]*list.Element
+	}
+
+	// DiskCache is Cache storing entries as files in directory.
+	DiskCache struct {
+		dir string
+	}
+
+	lruEntry struct {
+		key     string
+		value   []byte
+		expires time.Time
+	}
+
+	// responseCache is cache configured for the client. Entries are
+	// scoped to base url of the call which is set by responseCache.
+	responseCache struct {
+		store  Cache
+		policy CachePolicy
+		base   *url.URL
+		// tipOpts are call options used to fetch tip from base.
+		tipOpts []CallOption
+	}
+)
+
+// DefaultCachePolicy returns CachePolicy caching transactions and blocks
+// with at least 20 confirmations without expiry.
+func DefaultCachePolicy() CachePolicy {
+	return CachePolicy{
+		Confirmations: 20,
+		TipTTL:        20 * time.Second,
+	}
+}
+
+// ResponseCache enables caching of immutable chain data
+// using provided cache store and policy.
+func ResponseCache(cache Cache, policy CachePolicy) Option {
+	return func(c *Client) error {
+		if cache == nil {
+			return ErrCacheNil
+		}
+		c.mux.Lock()
+		defer c.mux.Unlock()
+		c.cache = &responseCache{
+			store:  cache,
+			policy: policy,
+		}
+		return nil
+	}
+}
+
+// NewLRUCache returns in-memory cache holding up to size entries.
+func NewLRUCache(size int) *LRUCache {
+	if size < 1 {
+		size = 1
+	}
+	return &LRUCache{
+		size:    size,
+		ll:      list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Get implements Cache interface.
+func (lru *LRUCache) Get(key string) ([]byte, bool) {
+	lru.mux.Lock()
+	defer lru.mux.Unlock()
+	el, ok := lru.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*lruEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		lru.ll.Remove(el)
+		delete(lru.entries, key)
+		return nil, false
+	}
+	lru.ll.MoveToFront(el)
+	return entry.value, true
+}
+
+// Set implements Cache interface.
+func (lru *LRUCache) Set(key string, value []byte, ttl time.Duration) {
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+	lru.mux.Lock()
+	defer lru.mux.Unlock()
+	if el, ok := lru.entries[key]; ok {
+		entry := el.Value.(*lruEntry)
+		entry.value = value
+		entry.expires = expires
+		lru.ll.MoveToFront(el)
+		return
+	}
+	lru.entries[key] = lru.ll.PushFront(&lruEntry{key: key, value: value, expires: expires})
+	for lru.ll.Len() > lru.size {
+		el := lru.ll.Back()
+		lru.ll.Remove(el)
+		delete(lru.entries, el.Value.(*lruEntry).key)
+	}
+}
+
+// Len returns number of entries in the cache.
+func (lru *LRUCache) Len() int {
+	lru.mux.Lock()
+	defer lru.mux.Unlock()
+	return lru.ll.Len()
+}
+
+// NewDiskCache returns cache storing entries in directory dir,
+// directory is created if it does not exist.
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+// Get implements Cache interface.
+func (dc *DiskCache) Get(key string) ([]byte, bool) {
+	file := dc.file(key)
+	data, err := ioutil.ReadFile(file)
+	if err != nil || len(data) < 8 {
+		return nil, false
+	}
+	if expires := int64(binary.BigEndian.Uint64(data[:8])); expires > 0 &&
+		time.Now().UnixNano() > expires {
+		_ = os.Remove(file)
+		return nil, false
+	}
+	return data[8:], true
+}
+
+// Set implements Cache interface. Errors writing the entry are ignored
+// since missing entry only causes the data to be requested again.
+func (dc *DiskCache) Set(key string, value []byte, ttl time.Duration) {
+	var expires int64
+	if ttl > 0 {
+		expires = time.Now().Add(ttl).UnixNano()
+	}
+	data := make([]byte, 8+len(value))
+	binary.BigEndian.PutUint64(data[:8], uint64(expires))
+	copy(data[8:], value)
+
+	tmp, err := ioutil.TempFile(dc.dir, ".tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), dc.file(key)); err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
+
+func (dc *DiskCache) file(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(dc.dir, hex.EncodeToString(sum[:]))
+}
+
+// responseCache returns cache configured for the client or nil.
+// Cache is not used when queries are provided since they alter the response.
+// Returned cache is scoped to host of the call, so that calls sent to other
+// host with WithHost do not share entries with configured host.
+func (c *Client) responseCache(opts []CallOption) *responseCache {
+	cfg := newCallConfig(opts)
+	if len(cfg.queries) > 0 || cfg.err != nil {
+		return nil
+	}
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	if c.cache == nil {
+		return nil
+	}
+	rc := *c.cache
+	rc.base = c.url
+	if cfg.host != nil {
+		rc.base = cfg.host
+		rc.tipOpts = []CallOption{WithHost(cfg.host.String())}
+	}
+	return &rc
+}
+
+// key returns key scoped to base url of the call.
+func (rc *responseCache) key(path, id string) string {
+	return rc.base.String() + path + "/" + id
+}
+
+// get decodes cached entry into dest.
+func (rc *responseCache) get(key string, dest interface{}) bool {
+	data, ok := rc.store.Get(key)
+	if !ok {
+		return false
+	}
+	return json.Unmarshal(data, dest) == nil
+}
+
+// set encodes v and stores it as immutable entry.
+func (rc *responseCache) set(key string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	rc.store.Set(key, data, rc.policy.TTL)
+}
+
+// cachedTip returns chain tip of the host of the call used to evaluate
+// immutability of the data, tip is cached for duration of CachePolicy.TipTTL.
+// Callers fetch it once per call and evaluate all returned items against it.
+func (c *Client) cachedTip(ctx context.Context, rc *responseCache) (*Tip, error) {
+	key := rc.key("tip", "")
+	tip := &Tip{}
+	if rc.get(key, tip) {
+		return tip, nil
+	}
+	res, err := c.GetTip(ctx, rc.tipOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if res.Data == nil {
+		return nil, res.applyError(nil, ErrNoTip)
+	}
+	if data, err := json.Marshal(res.Data); err == nil && rc.policy.TipTTL > 0 {
+		rc.store.Set(key, data, rc.policy.TipTTL)
+	}
+	return res.Data, nil
+}
+
+// isFinal reports whether block at height has enough confirmations
+// at tip, tip is nil when it could not be fetched.
+func (rc *responseCache) isFinal(tip *Tip, height int) bool {
+	return tip != nil && tip.BlockNo >= height && uint64(tip.BlockNo-height) >= rc.policy.Confirmations
+}
+
+// isPastEpoch reports whether epoch is older than epoch of tip.
+func (rc *responseCache) isPastEpoch(tip *Tip, epoch EpochNo) bool {
+	return tip != nil && tip.Epoch >= 0 && epoch < EpochNo(tip.Epoch)
+}
+
+// applyCached populates response metadata of response served from cache
+// and records the call with Telemetry of the client.
+func (c *Client) applyCached(
+	ctx context.Context,
+	rc *responseCache,
+	res *Response,
+	method string,
+	path string,
+	query url.Values,
+) {
+	u := rc.base.ResolveReference(&url.URL{Path: path, RawQuery: query.Encode()})
+	c.mux.RLock()
+	telemetry := c.telemetry
+	c.mux.RUnlock()
+	if telemetry != nil {
+		trackCached(ctx, telemetry, path, method, u.String())
+	}
+	res.RequestURL = u.String()
+	res.RequestMethod = method
+	res.StatusCode = http.StatusOK
+	res.Status = "200 OK"
+	res.Cached = true
+	res.ready()
+}
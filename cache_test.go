@@ -0,0 +1,299 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/howijd/koios-rest-go-client"
+)
+
+// chainServer serves immutable chain data at tip block 1000 of epoch 300
+// and counts requests per endpoint. Transactions prefixed with "final"
+// are in block 900, "recent" in block 995 and "bad" fail the request.
+// Tip and network magic of other networks can be set with tip and magic.
+type chainServer struct {
+	mu       sync.Mutex
+	requests map[string]int
+	tip      int
+	magic    string
+}
+
+func (s *chainServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	endpoint := strings.TrimPrefix(r.URL.Path, "/api/v0/")
+	s.mu.Lock()
+	s.requests[endpoint]++
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch endpoint {
+	case "tip":
+		tip := 1000
+		if s.tip > 0 {
+			tip = s.tip
+		}
+		fmt.Fprintf(w, `[{"block_no":%d,"epoch":300}]`, tip)
+	case "tx_info":
+		var payload struct {
+			TxHashes []string `json:"_tx_hashes"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		var infos []map[string]interface{}
+		for _, tx := range payload.TxHashes {
+			height := 900
+			switch {
+			case strings.HasPrefix(tx, "bad"):
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"message":"bad tx"}`)
+				return
+			case strings.HasPrefix(tx, "recent"):
+				height = 995
+			}
+			infos = append(infos, map[string]interface{}{"tx_hash": tx, "block_height": height})
+		}
+		_ = json.NewEncoder(w).Encode(infos)
+	case "block_txs":
+		fmt.Fprint(w, `[{"tx_hash":"tx1"},{"tx_hash":"tx2"}]`)
+	case "epoch_params":
+		fmt.Fprintf(w, `[{"epoch_no":%s}]`, r.URL.Query().Get("_epoch_no"))
+	case "genesis":
+		magic := "764824073"
+		if len(s.magic) > 0 {
+			magic = s.magic
+		}
+		fmt.Fprintf(w, `[{"networkmagic":"%s"}]`, magic)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *chainServer) count(endpoint string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[endpoint]
+}
+
+func newCacheTestClient(t *testing.T, policy koios.CachePolicy, opts ...koios.Option) (*koios.Client, *chainServer) {
+	srv := &chainServer{requests: make(map[string]int)}
+	opts = append([]koios.Option{koios.ResponseCache(koios.NewLRUCache(100), policy)}, opts...)
+	return newTestClient(t, srv, opts...), srv
+}
+
+func TestLRUCache(t *testing.T) {
+	lru := koios.NewLRUCache(2)
+	lru.Set("a", []byte("1"), 0)
+	lru.Set("b", []byte("2"), 0)
+
+	// a becomes most recently used, b is evicted.
+	v, ok := lru.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), v)
+	lru.Set("c", []byte("3"), 0)
+	assert.Equal(t, 2, lru.Len())
+	_, ok = lru.Get("b")
+	assert.False(t, ok)
+	_, ok = lru.Get("c")
+	assert.True(t, ok)
+
+	lru.Set("a", []byte("4"), 0)
+	v, _ = lru.Get("a")
+	assert.Equal(t, []byte("4"), v)
+	assert.Equal(t, 2, lru.Len())
+}
+
+func TestCacheTTL(t *testing.T) {
+	disk, err := koios.NewDiskCache(t.TempDir())
+	assert.NoError(t, err)
+
+	for name, cache := range map[string]koios.Cache{"lru": koios.NewLRUCache(10), "disk": disk} {
+		cache.Set("expired", []byte("1"), time.Nanosecond)
+		cache.Set("forever", []byte("2"), 0)
+		cache.Set("hour", []byte("3"), time.Hour)
+		time.Sleep(time.Millisecond)
+
+		_, ok := cache.Get("expired")
+		assert.False(t, ok, name)
+		v, ok := cache.Get("forever")
+		assert.True(t, ok, name)
+		assert.Equal(t, []byte("2"), v, name)
+		v, ok = cache.Get("hour")
+		assert.True(t, ok, name)
+		assert.Equal(t, []byte("3"), v, name)
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	dir := t.TempDir() + "/cache"
+	disk, err := koios.NewDiskCache(dir)
+	assert.NoError(t, err)
+	disk.Set("key", []byte("value"), 0)
+	_, ok := disk.Get("missing")
+	assert.False(t, ok)
+
+	// entries survive reopening of the cache.
+	disk, err = koios.NewDiskCache(dir)
+	assert.NoError(t, err)
+	v, ok := disk.Get("key")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("value"), v)
+
+	disk.Set("key", []byte("other"), 0)
+	v, _ = disk.Get("key")
+	assert.Equal(t, []byte("other"), v)
+}
+
+func TestResponseCacheTxsInfos(t *testing.T) {
+	policy := koios.DefaultCachePolicy()
+	policy.TipTTL = 0
+	api, srv := newCacheTestClient(t, policy)
+	ctx := context.Background()
+
+	txs := []koios.TxHash{"final1", "recent1", "final2", "final3"}
+	res, err := api.GetTxsInfos(ctx, txs)
+	assert.NoError(t, err)
+	assert.False(t, res.Cached)
+	assert.Len(t, res.Data, 4)
+	// tip is fetched once per call.
+	assert.Equal(t, 1, srv.count("tip"))
+
+	// only transaction which is not final is requested again.
+	res, err = api.GetTxsInfos(ctx, txs)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, srv.count("tx_info"))
+	if assert.Len(t, res.Data, 4) {
+		for i, tx := range txs {
+			assert.Equal(t, tx, res.Data[i].TxHash)
+		}
+	}
+
+	res, err = api.GetTxsInfos(ctx, []koios.TxHash{"final2", "final1"})
+	assert.NoError(t, err)
+	assert.True(t, res.Cached)
+	assert.Equal(t, 2, srv.count("tx_info"))
+	assert.Equal(t, koios.TxHash("final2"), res.Data[0].TxHash)
+
+	// queries bypass the cache.
+	_, err = api.GetTxsInfos(ctx, []koios.TxHash{"final1"}, koios.NewQuery())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, srv.count("tx_info"))
+}
+
+func TestResponseCacheTxsInfosBulkError(t *testing.T) {
+	api, srv := newCacheTestClient(t, koios.DefaultCachePolicy(), koios.BulkChunking(2, 1))
+	ctx := context.Background()
+
+	_, err := api.GetTxsInfos(ctx, []koios.TxHash{"final1"})
+	assert.NoError(t, err)
+
+	res, err := api.GetTxsInfos(ctx, []koios.TxHash{"final1", "final2", "final3", "bad1", "bad2"})
+	var bulkErr *koios.BulkError
+	assert.ErrorAs(t, err, &bulkErr)
+	if assert.Len(t, res.Data, 3) {
+		assert.Equal(t, koios.TxHash("final1"), res.Data[0].TxHash)
+		assert.Equal(t, koios.TxHash("final2"), res.Data[1].TxHash)
+		assert.Equal(t, koios.TxHash("final3"), res.Data[2].TxHash)
+	}
+
+	// transactions fetched before the error were cached.
+	requests := srv.count("tx_info")
+	res, err = api.GetTxsInfos(ctx, []koios.TxHash{"final2", "final3"})
+	assert.NoError(t, err)
+	assert.True(t, res.Cached)
+	assert.Equal(t, requests, srv.count("tx_info"))
+}
+
+func TestResponseCacheEndpoints(t *testing.T) {
+	api, srv := newCacheTestClient(t, koios.DefaultCachePolicy())
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		res, err := api.GetBlockTxHashes(ctx, "hash")
+		assert.NoError(t, err)
+		assert.Equal(t, i == 1, res.Cached)
+		assert.Equal(t, []koios.TxHash{"tx1", "tx2"}, res.Data)
+
+		genesis, err := api.GetGenesis(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, i == 1, genesis.Cached)
+		assert.Equal(t, "764824073", genesis.Data.Networkmagic)
+	}
+	assert.Equal(t, 1, srv.count("block_txs"))
+	assert.Equal(t, 1, srv.count("genesis"))
+
+	// parameters of past epochs are cached, of current epoch are not.
+	past, current := koios.EpochNo(299), koios.EpochNo(300)
+	for i := 0; i < 2; i++ {
+		res, err := api.GetEpochParams(ctx, &past)
+		assert.NoError(t, err)
+		assert.Equal(t, i == 1, res.Cached)
+		_, err = api.GetEpochParams(ctx, &current)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 3, srv.count("epoch_params"))
+	// tip is cached for CachePolicy.TipTTL.
+	assert.Equal(t, 1, srv.count("tip"))
+}
+
+func TestResponseCacheWithHost(t *testing.T) {
+	api, srv := newCacheTestClient(t, koios.DefaultCachePolicy())
+	other := &chainServer{requests: make(map[string]int), tip: 905, magic: "1"}
+	ts := httptest.NewServer(other)
+	t.Cleanup(ts.Close)
+	host := koios.WithHost(ts.URL + "/api/v0")
+	ctx := context.Background()
+
+	// entries of other host are not shared with configured host.
+	for i := 0; i < 2; i++ {
+		res, err := api.GetGenesis(ctx, host)
+		assert.NoError(t, err)
+		assert.Equal(t, i == 1, res.Cached)
+		assert.Equal(t, "1", res.Data.Networkmagic)
+		assert.Equal(t, ts.URL+"/api/v0/genesis", res.RequestURL)
+
+		res, err = api.GetGenesis(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, i == 1, res.Cached)
+		assert.Equal(t, "764824073", res.Data.Networkmagic)
+	}
+	assert.Equal(t, 1, srv.count("genesis"))
+	assert.Equal(t, 1, other.count("genesis"))
+
+	// finality is evaluated against tip of the other host.
+	for i := 0; i < 2; i++ {
+		res, err := api.GetTxsInfos(ctx, []koios.TxHash{"final1"}, host)
+		assert.NoError(t, err)
+		assert.False(t, res.Cached)
+	}
+	assert.Equal(t, 2, other.count("tx_info"))
+	assert.Equal(t, 1, other.count("tip"))
+	assert.Equal(t, 0, srv.count("tip"))
+}
+
+func TestResponseCacheOption(t *testing.T) {
+	_, err := koios.New(koios.ResponseCache(nil, koios.DefaultCachePolicy()))
+	assert.ErrorIs(t, err, koios.ErrCacheNil)
+}
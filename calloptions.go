@@ -0,0 +1,140 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type (
+	// CallOption configures single API call e.g. its timeout or headers.
+	// All methods of the API client accept call options, *Query is
+	// CallOption too.
+	CallOption interface {
+		applyCall(cfg *callConfig)
+	}
+
+	callOptionFunc func(cfg *callConfig)
+
+	// callConfig is configuration of single API call.
+	callConfig struct {
+		queries  []*Query
+		header   http.Header
+		timeout  time.Duration
+		host     *url.URL
+		priority *Priority
+		err      error
+	}
+
+	// cancelBody cancels call context when response body is closed.
+	cancelBody struct {
+		io.ReadCloser
+		cancel context.CancelFunc
+	}
+)
+
+// WithTimeout limits duration of the call including reading
+// of the response body to d.
+func WithTimeout(d time.Duration) CallOption {
+	return callOptionFunc(func(cfg *callConfig) {
+		cfg.timeout = d
+	})
+}
+
+// WithHeader adds header to the request of the call.
+func WithHeader(name, value string) CallOption {
+	return callOptionFunc(func(cfg *callConfig) {
+		if cfg.header == nil {
+			cfg.header = http.Header{}
+		}
+		cfg.header.Add(name, value)
+	})
+}
+
+// WithExactCount requests exact count of rows matching the request
+// with "Prefer: count=exact" header. Total count is then reported by
+// Response.PageInfo.
+func WithExactCount() CallOption {
+	return WithHeader("Prefer", "count=exact")
+}
+
+// WithHost sends the call to Koios instance with provided base url
+// e.g. https://koios.example.com/api/v0 instead of configured host
+// or instances. Useful e.g. for single heavy query. Responses cached
+// for the host are not shared with configured host and addresses are
+// not validated against network selected with UseNetwork.
+func WithHost(baseURL string) CallOption {
+	return callOptionFunc(func(cfg *callConfig) {
+		u, err := url.ParseRequestURI(baseURL)
+		if err != nil {
+			cfg.err = err
+			return
+		}
+		if !strings.HasSuffix(u.Path, "/") {
+			u.Path += "/"
+		}
+		cfg.host = u
+	})
+}
+
+func (f callOptionFunc) applyCall(cfg *callConfig) {
+	f(cfg)
+}
+
+func (q *Query) applyCall(cfg *callConfig) {
+	if q != nil {
+		cfg.queries = append(cfg.queries, q)
+	}
+}
+
+func newCallConfig(opts []CallOption) *callConfig {
+	cfg := &callConfig{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt.applyCall(cfg)
+		}
+	}
+	return cfg
+}
+
+// headers returns headers extended with headers set by call options.
+func (cfg *callConfig) headers(headers http.Header) http.Header {
+	if len(cfg.header) == 0 {
+		return headers
+	}
+	merged := headers.Clone()
+	if merged == nil {
+		merged = http.Header{}
+	}
+	for name, values := range cfg.header {
+		for _, value := range values {
+			merged.Add(name, value)
+		}
+	}
+	return merged
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
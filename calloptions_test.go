@@ -0,0 +1,146 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/howijd/koios-rest-go-client"
+)
+
+func TestCallOptions(t *testing.T) {
+	var last *http.Request
+	api := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		last = r
+		if r.URL.Path == "/api/v0/tip" && r.Header.Get("X-Slow") == "1" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("[]"))
+	}))
+	ctx := context.Background()
+
+	_, err := api.GetPoolList(ctx,
+		koios.WithHeader("X-Request-ID", "42"),
+		koios.WithExactCount(),
+		koios.NewQuery().Select("pool_id_bech32"),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "42", last.Header.Get("X-Request-ID"))
+	assert.Equal(t, "count=exact", last.Header.Get("Prefer"))
+	assert.Equal(t, "pool_id_bech32", last.URL.Query().Get("select"))
+
+	_, err = api.GetTip(ctx, koios.WithTimeout(20*time.Millisecond), koios.WithHeader("X-Slow", "1"))
+	assert.True(t, errors.Is(err, koios.ErrTimeout), "expected timeout got: %v", err)
+
+	other := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		other++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("[]"))
+	}))
+	defer ts.Close()
+	res, err := api.GetTip(ctx, koios.WithHost(ts.URL+"/api/v0"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, other)
+	assert.Equal(t, ts.URL+"/api/v0/tip", res.RequestURL)
+
+	_, err = api.GetTip(ctx, koios.WithHost("::invalid"))
+	assert.Error(t, err)
+}
+
+func TestWithHostScope(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests = map[string]int{}
+	)
+	handler := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			endpoint := strings.TrimPrefix(r.URL.Path, "/api/v0/")
+			mu.Lock()
+			requests[name+"/"+endpoint]++
+			mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			switch endpoint {
+			case "tip":
+				_, _ = w.Write([]byte(`[{"block_no":1000,"epoch":300}]`))
+			case "epoch_params":
+				_, _ = w.Write([]byte(`[{"block_hash":"` + name + `"}]`))
+			default:
+				_, _ = w.Write([]byte("[]"))
+			}
+		})
+	}
+	count := func(endpoint string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests[endpoint]
+	}
+	ts := httptest.NewServer(handler("other"))
+	defer ts.Close()
+	host := koios.WithHost(ts.URL + "/api/v0")
+
+	api := newTestClient(t, handler("configured"),
+		koios.ResponseCache(koios.NewLRUCache(10), koios.DefaultCachePolicy()),
+		koios.UseNetwork(koios.Network{
+			Name:               "local",
+			Host:               "127.0.0.1",
+			AddressPrefix:      "addr",
+			StakeAddressPrefix: "stake",
+		}),
+	)
+	ctx := context.Background()
+
+	// cache entries of other host are not shared.
+	epoch := koios.EpochNo(299)
+	for i := 0; i < 2; i++ {
+		res, err := api.GetEpochParams(ctx, &epoch, host)
+		assert.NoError(t, err)
+		assert.Equal(t, i == 1, res.Cached)
+		if assert.Len(t, res.Data, 1) {
+			assert.Equal(t, "other", res.Data[0].BlockHash)
+		}
+
+		res, err = api.GetEpochParams(ctx, &epoch)
+		assert.NoError(t, err)
+		assert.Equal(t, i == 1, res.Cached)
+		if assert.Len(t, res.Data, 1) {
+			assert.Equal(t, "configured", res.Data[0].BlockHash)
+		}
+	}
+	assert.Equal(t, 1, count("other/epoch_params"))
+	assert.Equal(t, 1, count("other/tip"))
+	assert.Equal(t, 1, count("configured/epoch_params"))
+	assert.Equal(t, 1, count("configured/tip"))
+
+	// addresses are not validated against network of the client.
+	stake := koios.StakeAddress("stake_test1uqehkck0lajq8gr28t9uxnuvgcqrc6070x3k9r8048z8y5gssrtvn")
+	_, err := api.GetAccountRewards(ctx, stake, nil)
+	assert.ErrorIs(t, err, koios.ErrNetworkMismatch)
+	_, err = api.GetAccountRewards(ctx, stake, nil, host)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count("other/account_rewards"))
+}
@@ -0,0 +1,425 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Address types defined by CIP-19 header of Shelley addresses,
+// Byron addresses are reported as AddressByron.
+//
+// Base addresses carry payment and stake credential, pointer addresses
+// payment credential and pointer to stake registration certificate,
+// enterprise addresses only payment credential and reward (stake)
+// addresses only stake credential.
+const (
+	AddressBaseKeyKey AddressType = iota
+	AddressBaseScriptKey
+	AddressBaseKeyScript
+	AddressBaseScriptScript
+	AddressPointerKey
+	AddressPointerScript
+	AddressEnterpriseKey
+	AddressEnterpriseScript
+	AddressByron
+	AddressRewardKey    AddressType = 14
+	AddressRewardScript AddressType = 15
+)
+
+// Types of credentials.
+//
+// CredentialKey   : hash of verification key.
+// CredentialScript: hash of script.
+const (
+	CredentialKey CredentialType = iota
+	CredentialScript
+)
+
+const (
+	credentialHashLen = 28
+
+	// Network ids of CIP-19 header.
+	testnetNetworkID uint8 = 0
+	mainnetNetworkID uint8 = 1
+)
+
+type (
+	// AddressType is type of the address from CIP-19 address header.
+	AddressType uint8
+
+	// CredentialType tells whether credential is key or script hash.
+	CredentialType uint8
+
+	// Credential is payment or stake credential of the address.
+	Credential struct {
+		Type CredentialType
+
+		// Hash is 28 bytes blake2b-224 hash of key or script.
+		Hash []byte
+	}
+
+	// StakePointer points to stake registration certificate
+	// of pointer address.
+	StakePointer struct {
+		Slot      uint64
+		TxIndex   uint64
+		CertIndex uint64
+	}
+
+	// DecodedAddress is address decoded by ParseAddress.
+	DecodedAddress struct {
+		// Type of the address.
+		Type AddressType
+
+		// NetworkID from address header, 1 for mainnet and 0 for test networks.
+		// Byron addresses without protocol magic are treated as mainnet ones.
+		NetworkID uint8
+
+		// Prefix is bech32 human readable part, empty for Byron addresses.
+		Prefix string
+
+		// Payment credential, nil for Byron and reward addresses.
+		Payment *Credential
+
+		// Stake credential of base and reward addresses.
+		Stake *Credential
+
+		// Pointer of pointer addresses.
+		Pointer *StakePointer
+
+		// ByronMagic is protocol magic of Byron address
+		// of test network, 0 when it is not present.
+		ByronMagic uint32
+
+		// Raw address bytes.
+		Raw []byte
+	}
+)
+
+// ParseAddress decodes and validates bech32 encoded Shelley payment
+// or stake address or base58 encoded Byron address. Returned error
+// matches ErrInvalidAddress.
+func ParseAddress(addr string) (*DecodedAddress, error) {
+	if len(addr) == 0 {
+		return nil, ErrNoAddress
+	}
+	if lower := strings.ToLower(addr); strings.HasPrefix(lower, "addr") || strings.HasPrefix(lower, "stake") {
+		return parseShelleyAddress(addr)
+	}
+	raw, err := base58Decode(addr)
+	if err == nil {
+		var d *DecodedAddress
+		if d, err = parseByronAddress(raw); err == nil {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s: %s", ErrInvalidAddress, addr, err)
+}
+
+// Decode decodes the address, see ParseAddress.
+func (a Address) Decode() (*DecodedAddress, error) {
+	return ParseAddress(string(a))
+}
+
+// Valid reports whether the address is well formed.
+func (a Address) Valid() bool {
+	_, err := ParseAddress(string(a))
+	return err == nil
+}
+
+// StakeAddress returns reward address of stake credential of base address,
+// e.g. for GetAccountInfo. Reward address is returned as is.
+// Error matching ErrNoStakeCredential is returned for enterprise, pointer
+// and Byron addresses.
+func (a Address) StakeAddress() (StakeAddress, error) {
+	d, err := a.Decode()
+	if err != nil {
+		return "", err
+	}
+	return d.StakeAddress()
+}
+
+// PaymentCredential returns hex encoded payment credential of the address
+// e.g. for GetCredentialTxs. Error matching ErrNoPaymentCredential
+// is returned for reward and Byron addresses.
+func (a Address) PaymentCredential() (PaymentCredential, error) {
+	d, err := a.Decode()
+	if err != nil {
+		return "", err
+	}
+	return d.PaymentCredential()
+}
+
+// Decode decodes the stake address. Error matching ErrInvalidAddress
+// is returned when it is not reward address.
+func (s StakeAddress) Decode() (*DecodedAddress, error) {
+	d, err := ParseAddress(string(s))
+	if err != nil {
+		return nil, err
+	}
+	if !d.Type.IsReward() {
+		return nil, fmt.Errorf("%w: %s: not a stake address", ErrInvalidAddress, s)
+	}
+	return d, nil
+}
+
+// Valid reports whether the stake address is well formed.
+func (s StakeAddress) Valid() bool {
+	_, err := s.Decode()
+	return err == nil
+}
+
+// Credential returns stake credential of the stake address.
+func (s StakeAddress) Credential() (*Credential, error) {
+	d, err := s.Decode()
+	if err != nil {
+		return nil, err
+	}
+	return d.Stake, nil
+}
+
+// StakeAddress returns reward address of stake credential of the address.
+func (d *DecodedAddress) StakeAddress() (StakeAddress, error) {
+	if d.Type.IsReward() {
+		return StakeAddress(d.String()), nil
+	}
+	if d.Stake == nil {
+		return "", fmt.Errorf("%w: %s address", ErrNoStakeCredential, d.Type)
+	}
+	return NewStakeAddress(d.NetworkID, *d.Stake)
+}
+
+// PaymentCredential returns hex encoded payment credential of the address.
+func (d *DecodedAddress) PaymentCredential() (PaymentCredential, error) {
+	if d.Payment == nil {
+		return "", fmt.Errorf("%w: %s address", ErrNoPaymentCredential, d.Type)
+	}
+	return PaymentCredential(d.Payment.String()), nil
+}
+
+// String returns bech32 encoded Shelley address or base58
+// encoded Byron address.
+func (d *DecodedAddress) String() string {
+	if d.Type == AddressByron {
+		return base58Encode(d.Raw)
+	}
+	return bech32Encode(d.Prefix, d.Raw)
+}
+
+// NewStakeAddress returns bech32 encoded reward address of stake credential
+// cred on network with networkID (1 for mainnet, 0 for test networks).
+func NewStakeAddress(networkID uint8, cred Credential) (StakeAddress, error) {
+	if len(cred.Hash) != credentialHashLen {
+		return "", fmt.Errorf("%w: credential hash must be %d bytes", ErrInvalidAddress, credentialHashLen)
+	}
+	if networkID > 15 {
+		return "", fmt.Errorf("%w: network id %d", ErrInvalidAddress, networkID)
+	}
+	typ := AddressRewardKey
+	if cred.Type == CredentialScript {
+		typ = AddressRewardScript
+	}
+	raw := append([]byte{byte(typ)<<4 | networkID}, cred.Hash...)
+	return StakeAddress(bech32Encode(stakePrefix(networkID), raw)), nil
+}
+
+// IsBase reports whether address type is base address.
+func (t AddressType) IsBase() bool {
+	return t <= AddressBaseScriptScript
+}
+
+// IsPointer reports whether address type is pointer address.
+func (t AddressType) IsPointer() bool {
+	return t == AddressPointerKey || t == AddressPointerScript
+}
+
+// IsEnterprise reports whether address type is enterprise address.
+func (t AddressType) IsEnterprise() bool {
+	return t == AddressEnterpriseKey || t == AddressEnterpriseScript
+}
+
+// IsReward reports whether address type is reward (stake) address.
+func (t AddressType) IsReward() bool {
+	return t == AddressRewardKey || t == AddressRewardScript
+}
+
+// String returns name of the address type.
+func (t AddressType) String() string {
+	switch {
+	case t.IsBase():
+		return "base"
+	case t.IsPointer():
+		return "pointer"
+	case t.IsEnterprise():
+		return "enterprise"
+	case t.IsReward():
+		return "reward"
+	case t == AddressByron:
+		return "byron"
+	}
+	return "unknown"
+}
+
+// String returns name of the credential type.
+func (t CredentialType) String() string {
+	switch t {
+	case CredentialKey:
+		return "key"
+	case CredentialScript:
+		return "script"
+	}
+	return "unknown"
+}
+
+// String returns hex encoded credential hash.
+func (c Credential) String() string {
+	return hex.EncodeToString(c.Hash)
+}
+
+func parseShelleyAddress(addr string) (*DecodedAddress, error) {
+	hrp, raw, err := bech32Decode(addr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", ErrInvalidAddress, addr, err)
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("%w: %s: empty address", ErrInvalidAddress, addr)
+	}
+	d := &DecodedAddress{
+		Type:      AddressType(raw[0] >> 4),
+		NetworkID: raw[0] & 0x0f,
+		Prefix:    hrp,
+		Raw:       raw,
+	}
+	if err = d.parseShelley(raw[1:]); err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", ErrInvalidAddress, addr, err)
+	}
+	return d, nil
+}
+
+func (d *DecodedAddress) parseShelley(body []byte) error {
+	var want string
+	if d.Type.IsReward() {
+		want = stakePrefix(d.NetworkID)
+	} else {
+		want = addressPrefix(d.NetworkID)
+	}
+	if d.Prefix != want {
+		return fmt.Errorf("%s address of network %d must have %s prefix", d.Type, d.NetworkID, want)
+	}
+
+	switch {
+	case d.Type.IsBase():
+		if len(body) != 2*credentialHashLen {
+			return fmt.Errorf("invalid length of base address")
+		}
+		d.Payment = newCredential(body[:credentialHashLen], d.Type&1 == 1)
+		d.Stake = newCredential(body[credentialHashLen:], d.Type&2 == 2)
+	case d.Type.IsPointer():
+		if len(body) < credentialHashLen {
+			return fmt.Errorf("invalid length of pointer address")
+		}
+		d.Payment = newCredential(body[:credentialHashLen], d.Type&1 == 1)
+		ptr, err := parseStakePointer(body[credentialHashLen:])
+		if err != nil {
+			return err
+		}
+		d.Pointer = ptr
+	case d.Type.IsEnterprise():
+		if len(body) != credentialHashLen {
+			return fmt.Errorf("invalid length of enterprise address")
+		}
+		d.Payment = newCredential(body, d.Type&1 == 1)
+	case d.Type.IsReward():
+		if len(body) != credentialHashLen {
+			return fmt.Errorf("invalid length of reward address")
+		}
+		d.Stake = newCredential(body, d.Type&1 == 1)
+	default:
+		return fmt.Errorf("unsupported address header type %d", d.Type)
+	}
+	return nil
+}
+
+func parseByronAddress(raw []byte) (*DecodedAddress, error) {
+	magic, hasMagic, err := decodeByronAddress(raw)
+	if err != nil {
+		return nil, err
+	}
+	d := &DecodedAddress{
+		Type:      AddressByron,
+		NetworkID: mainnetNetworkID,
+		Raw:       raw,
+	}
+	if hasMagic {
+		d.NetworkID = testnetNetworkID
+		d.ByronMagic = magic
+	}
+	return d, nil
+}
+
+// parseStakePointer decodes three variable length natural numbers
+// of pointer address.
+func parseStakePointer(b []byte) (*StakePointer, error) {
+	var nums [3]uint64
+	for i := range nums {
+		var (
+			v    uint64
+			done bool
+		)
+		for len(b) > 0 && !done {
+			if v > 1<<57-1 {
+				return nil, fmt.Errorf("stake pointer overflows")
+			}
+			v = v<<7 | uint64(b[0]&0x7f)
+			done = b[0]&0x80 == 0
+			b = b[1:]
+		}
+		if !done {
+			return nil, fmt.Errorf("truncated stake pointer")
+		}
+		nums[i] = v
+	}
+	if len(b) != 0 {
+		return nil, fmt.Errorf("invalid length of pointer address")
+	}
+	return &StakePointer{Slot: nums[0], TxIndex: nums[1], CertIndex: nums[2]}, nil
+}
+
+func newCredential(hash []byte, script bool) *Credential {
+	c := &Credential{Hash: append([]byte(nil), hash...)}
+	if script {
+		c.Type = CredentialScript
+	}
+	return c
+}
+
+func addressPrefix(networkID uint8) string {
+	if networkID == mainnetNetworkID {
+		return Mainnet.AddressPrefix
+	}
+	return Preprod.AddressPrefix
+}
+
+func stakePrefix(networkID uint8) string {
+	if networkID == mainnetNetworkID {
+		return Mainnet.StakeAddressPrefix
+	}
+	return Preprod.StakeAddressPrefix
+}
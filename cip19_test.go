@@ -0,0 +1,203 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/howijd/koios-rest-go-client"
+)
+
+// Test vectors from CIP-19.
+const (
+	testPaymentKeyHash  = "9493315cd92eb5d8c4304e67b7e16ae36d61d34502694657811a2c8e"
+	testPaymentScript   = "c37b1b5dc0669f1d3c61a6fddb2e8fde96be87b881c60bce8e8d542f"
+	testStakeKeyHash    = "337b62cfff6403a06a3acbc34f8c46003c69fe79a3628cefa9c47251"
+	testStakeKeyAddress = "stake1uyehkck0lajq8gr28t9uxnuvgcqrc6070x3k9r8048z8y5gh6ffgw"
+)
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		addr    string
+		typ     koios.AddressType
+		payment string
+		pcred   koios.CredentialType
+		stake   string
+		scred   koios.CredentialType
+	}{
+		{
+			"addr1qx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer3n0d3vllmyqwsx5wktcd8cc3sq835lu7drv2xwl2wywfgse35a3x",
+			koios.AddressBaseKeyKey, testPaymentKeyHash, koios.CredentialKey, testStakeKeyHash, koios.CredentialKey,
+		},
+		{
+			"addr1z8phkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gten0d3vllmyqwsx5wktcd8cc3sq835lu7drv2xwl2wywfgs9yc0hh",
+			koios.AddressBaseScriptKey, testPaymentScript, koios.CredentialScript, testStakeKeyHash, koios.CredentialKey,
+		},
+		{
+			"addr1yx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzerkr0vd4msrxnuwnccdxlhdjar77j6lg0wypcc9uar5d2shs2z78ve",
+			koios.AddressBaseKeyScript, testPaymentKeyHash, koios.CredentialKey, testPaymentScript, koios.CredentialScript,
+		},
+		{
+			"addr1x8phkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gt7r0vd4msrxnuwnccdxlhdjar77j6lg0wypcc9uar5d2shskhj42g",
+			koios.AddressBaseScriptScript, testPaymentScript, koios.CredentialScript, testPaymentScript, koios.CredentialScript,
+		},
+		{
+			"addr1gx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer5pnz75xxcrzqf96k",
+			koios.AddressPointerKey, testPaymentKeyHash, koios.CredentialKey, "", 0,
+		},
+		{
+			"addr1vx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzers66hrl8",
+			koios.AddressEnterpriseKey, testPaymentKeyHash, koios.CredentialKey, "", 0,
+		},
+		{
+			"addr1w8phkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gtcyjy7wx",
+			koios.AddressEnterpriseScript, testPaymentScript, koios.CredentialScript, "", 0,
+		},
+		{
+			testStakeKeyAddress,
+			koios.AddressRewardKey, "", 0, testStakeKeyHash, koios.CredentialKey,
+		},
+		{
+			"stake178phkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gtcccycj5",
+			koios.AddressRewardScript, "", 0, testPaymentScript, koios.CredentialScript,
+		},
+	}
+	for _, tt := range tests {
+		d, err := koios.ParseAddress(tt.addr)
+		if !assert.NoError(t, err, tt.addr) {
+			continue
+		}
+		assert.Equal(t, tt.typ, d.Type, tt.addr)
+		assert.Equal(t, uint8(1), d.NetworkID, tt.addr)
+		assert.Equal(t, tt.addr, d.String())
+		if tt.payment == "" {
+			assert.Nil(t, d.Payment, tt.addr)
+		} else if assert.NotNil(t, d.Payment, tt.addr) {
+			assert.Equal(t, tt.payment, d.Payment.String(), tt.addr)
+			assert.Equal(t, tt.pcred, d.Payment.Type, tt.addr)
+		}
+		if tt.stake == "" {
+			assert.Nil(t, d.Stake, tt.addr)
+		} else if assert.NotNil(t, d.Stake, tt.addr) {
+			assert.Equal(t, tt.stake, d.Stake.String(), tt.addr)
+			assert.Equal(t, tt.scred, d.Stake.Type, tt.addr)
+		}
+	}
+
+	d, err := koios.ParseAddress("addr1gx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer5pnz75xxcrzqf96k")
+	assert.NoError(t, err)
+	assert.Equal(t, &koios.StakePointer{Slot: 2498243, TxIndex: 27, CertIndex: 3}, d.Pointer)
+	assert.Equal(t, "pointer", d.Type.String())
+
+	d, err = koios.ParseAddress(
+		"addr_test1qz2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer3n0d3vllmyqwsx5wktcd8cc3sq835lu7drv2xwl2wywfgs68faae")
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0), d.NetworkID)
+	assert.Equal(t, "addr_test", d.Prefix)
+}
+
+func TestParseByronAddress(t *testing.T) {
+	for _, addr := range []string{
+		"Ae2tdPwUPEZFRbyhz3cpfC2CumGzNkFBN2L42rcUc2yjQpEkxDbkPodpMAi",
+		"DdzFFzCqrhsw3prhfMFDNFowbzUku3QmrMwarfjUbWXRisodn97R436SHc1rimp4MhPNmbdYb1aTdqtGSJixMVMi5MkArDQJ6Sc1n3Ez",
+	} {
+		d, err := koios.ParseAddress(addr)
+		if assert.NoError(t, err, addr) {
+			assert.Equal(t, koios.AddressByron, d.Type)
+			assert.Equal(t, uint8(1), d.NetworkID)
+			assert.Nil(t, d.Payment)
+			assert.Equal(t, addr, d.String())
+		}
+		_, err = koios.Address(addr).PaymentCredential()
+		assert.ErrorIs(t, err, koios.ErrNoPaymentCredential)
+		_, err = koios.Address(addr).StakeAddress()
+		assert.ErrorIs(t, err, koios.ErrNoStakeCredential)
+	}
+}
+
+func TestParseAddressInvalid(t *testing.T) {
+	for _, addr := range []string{
+		// checksum
+		"addr1vx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzers66hrl9",
+		// mixed case
+		"addr1vx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzers66hrL8",
+		// testnet prefix of mainnet address
+		"addr_test1vx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzerspqnws9",
+		// stake prefix of payment address
+		"stake1vx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzersuzp9l4",
+		// truncated enterprise address
+		"addr1vx2fxv2umyhttkxyxp8x0dlpdt3k6cwnumedgl",
+		// unsupported header type
+		"addr1jx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzerske8ra8",
+		// byron crc
+		"Ae2tdPwUPEZFRbyhz3cpfC2CumGzNkFBN2L42rcUc2yjQpEkxDbkPodpMAj",
+		"stake1",
+		"not an address",
+	} {
+		_, err := koios.ParseAddress(addr)
+		assert.ErrorIs(t, err, koios.ErrInvalidAddress, addr)
+		assert.False(t, koios.Address(addr).Valid(), addr)
+	}
+	_, err := koios.ParseAddress("")
+	assert.ErrorIs(t, err, koios.ErrNoAddress)
+
+	_, err = koios.StakeAddress("addr1vx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzers66hrl8").Decode()
+	assert.ErrorIs(t, err, koios.ErrInvalidAddress)
+}
+
+func TestAddressCredentials(t *testing.T) {
+	base := koios.Address(
+		"addr1qx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer3n0d3vllmyqwsx5wktcd8cc3sq835lu7drv2xwl2wywfgse35a3x")
+	stake, err := base.StakeAddress()
+	assert.NoError(t, err)
+	assert.Equal(t, koios.StakeAddress(testStakeKeyAddress), stake)
+	assert.True(t, stake.Valid())
+
+	cred, err := base.PaymentCredential()
+	assert.NoError(t, err)
+	assert.Equal(t, koios.PaymentCredential(testPaymentKeyHash), cred)
+
+	stake, err = koios.Address(
+		"addr_test1qz2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer3n0d3vllmyqwsx5wktcd8cc3sq835lu7drv2xwl2wywfgs68faae",
+	).StakeAddress()
+	assert.NoError(t, err)
+	assert.Equal(t, koios.StakeAddress("stake_test1uqehkck0lajq8gr28t9uxnuvgcqrc6070x3k9r8048z8y5gssrtvn"), stake)
+
+	_, err = koios.Address("addr1vx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzers66hrl8").StakeAddress()
+	assert.ErrorIs(t, err, koios.ErrNoStakeCredential)
+	_, err = koios.Address(testStakeKeyAddress).PaymentCredential()
+	assert.ErrorIs(t, err, koios.ErrNoPaymentCredential)
+
+	scred, err := koios.StakeAddress(testStakeKeyAddress).Credential()
+	assert.NoError(t, err)
+	assert.Equal(t, testStakeKeyHash, scred.String())
+
+	_, err = koios.NewStakeAddress(1, koios.Credential{Hash: []byte{1}})
+	assert.ErrorIs(t, err, koios.ErrInvalidAddress)
+}
+
+func TestValidateAddress(t *testing.T) {
+	api, err := koios.New()
+	assert.NoError(t, err)
+	res, err := api.GetAddressInfo(context.Background(), "addr1vx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzers66hrl9")
+	assert.ErrorIs(t, err, koios.ErrInvalidAddress)
+	assert.NotNil(t, res.Error)
+	assert.Equal(t, uint64(0), api.TotalRequests())
+}
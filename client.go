@@ -17,10 +17,12 @@
 package koios
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptrace"
 	"net/url"
@@ -35,8 +37,9 @@ func (c *Client) HEAD(
 	path string,
 	query url.Values,
 	headers http.Header,
+	opts ...CallOption,
 ) (*http.Response, error) {
-	return c.request(ctx, nil, "HEAD", path, nil, query, headers)
+	return c.request(ctx, nil, "HEAD", path, nil, query, headers, opts...)
 }
 
 // POST sends api http POST request to provided relative path with query params
@@ -49,8 +52,9 @@ func (c *Client) POST(
 	body io.Reader,
 	query url.Values,
 	headers http.Header,
+	opts ...CallOption,
 ) (*http.Response, error) {
-	return c.request(ctx, nil, "POST", path, body, query, headers)
+	return c.request(ctx, nil, "POST", path, body, query, headers, opts...)
 }
 
 // GET sends api http GET request to provided relative path with query params
@@ -62,8 +66,9 @@ func (c *Client) GET(
 	path string,
 	query url.Values,
 	headers http.Header,
+	opts ...CallOption,
 ) (*http.Response, error) {
-	return c.request(ctx, nil, "GET", path, nil, query, headers)
+	return c.request(ctx, nil, "GET", path, nil, query, headers, opts...)
 }
 
 // BaseURL returns currently used base url e.g. https://api.koios.rest/api/v0
@@ -80,6 +85,9 @@ func (c *Client) TotalRequests() uint64 {
 	return c.totalReq
 }
 
+// request sends api request. When res is provided it is populated
+// with response metadata and on failure with *ResponseError which is
+// then also returned as error.
 func (c *Client) request(
 	ctx context.Context,
 	res *Response,
@@ -87,66 +95,348 @@ func (c *Client) request(
 	path string,
 	body io.Reader,
 	query url.Values,
-	headers http.Header) (*http.Response, error) {
-	var (
-		requrl string
-	)
-
+	headers http.Header,
+	opts ...CallOption) (*http.Response, error) {
 	path = strings.TrimLeft(path, "/")
+	method = strings.ToUpper(method)
+
+	cfg := newCallConfig(opts)
+	if cfg.err != nil {
+		closeBody(body)
+		if res != nil {
+			return nil, res.applyError(nil, cfg.err)
+		}
+		return nil, cfg.err
+	}
+	query = withQuery(query, cfg.queries)
+	headers = cfg.headers(headers)
+
+	rel := &url.URL{Path: path}
+	if query != nil {
+		rel.RawQuery = query.Encode()
+	}
+
+	var cancel context.CancelFunc
+	if cfg.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, cfg.timeout)
+	}
+
 	c.mux.RLock()
-	if query == nil {
-		requrl = c.url.ResolveReference(&url.URL{Path: path}).String()
-	} else {
-		requrl = c.url.ResolveReference(&url.URL{Path: path, RawQuery: query.Encode()}).String()
+	telemetry := c.telemetry
+	exactCount := c.exactCount
+	logger := c.logger
+	c.mux.RUnlock()
+
+	if res != nil {
+		res.logger = logger
+	}
+
+	if exactCount && method == http.MethodGet && len(headers.Get("Prefer")) == 0 {
+		headers = headers.Clone()
+		if headers == nil {
+			headers = http.Header{}
+		}
+		headers.Set("Prefer", "count=exact")
+	}
+
+	var tracker *callTracker
+	if telemetry != nil {
+		ctx, tracker, body = startTracking(ctx, telemetry, path, method, body)
+	}
+
+	priority := defaultPriority(path)
+	if cfg.priority != nil {
+		priority = *cfg.priority
+	}
+	call := &Call{Endpoint: path, Response: res, Priority: priority}
+	rsp, err := c.send(ctx, call, method, path, rel, body, headers, cfg.host)
+	if tracker != nil {
+		rsp = tracker.done(call, rsp, err)
+	}
+	if cancel != nil {
+		if err != nil {
+			cancel()
+		} else {
+			// timeout applies to reading of the response body too.
+			rsp.Body = &cancelBody{ReadCloser: rsp.Body, cancel: cancel}
+		}
 	}
+	if err != nil && res != nil {
+		return nil, res.applyError(nil, err)
+	}
+	return rsp, err
+}
+
+// send sends the request to selected instance, failing over to other
+// instances and retrying it when allowed by retry policy.
+func (c *Client) send(
+	ctx context.Context,
+	call *Call,
+	method string,
+	path string,
+	rel *url.URL,
+	body io.Reader,
+	headers http.Header,
+	host *url.URL) (*http.Response, error) {
+	c.mux.RLock()
+	base := c.url
+	limiter := c.limiter
+	retry := c.retry
+	pool := c.instances
+	breakers := c.breakers
+	logger := c.logger
+	c.mux.RUnlock()
+
+	// host set for the call takes precedence over instances.
+	if host != nil {
+		base, pool = host, nil
+	}
+
+	retries := retry != nil && retry.allows(method, path)
+	failover := pool != nil && pool.len() > 1 &&
+		isIdempotent(method, path, retry != nil && retry.RetrySubmitTx)
+
+	if !retries && !failover {
+		var inst *instance
+		if pool != nil {
+			inst = pool.pick(nil)
+			base = inst.url
+		}
+		// fail fast when host is down.
+		if breakers != nil {
+			if err := breakers.allow(base.Host); err != nil {
+				closeBody(body)
+				return nil, err
+			}
+		}
+		call.Attempt = 1
+		rsp, err := c.attempt(ctx, call, limiter, method, base.ResolveReference(rel).String(), body, headers)
+		if inst != nil && ctx.Err() == nil && call.sent {
+			pool.report(inst, call.latency, isInstanceFailure(rsp, err))
+		}
+		if breakers != nil {
+			breakers.report(base.Host, isHostFailure(rsp, err), ctx.Err() != nil || !call.sent)
+		}
+		return rsp, err
+	}
+
+	// buffer the payload so that it can be resent.
+	var payload []byte
+	if body != nil {
+		b, err := ioutil.ReadAll(body)
+		closeBody(body)
+		if err != nil {
+			return nil, err
+		}
+		payload = b
+	}
+
+	tried := make(map[*instance]bool)
+	for attempt := 1; ; {
+		var inst *instance
+		if pool != nil {
+			inst = pool.pick(tried)
+			tried[inst] = true
+			base = inst.url
+		}
+		if breakers != nil {
+			if err := breakers.allow(base.Host); err != nil {
+				// fail over to instance which circuit is not open.
+				if failover && len(tried) < pool.len() {
+					continue
+				}
+				return nil, err
+			}
+		}
+
+		call.Attempt++
+		if payload != nil {
+			body = bytes.NewReader(payload)
+		}
+		rsp, err := c.attempt(ctx, call, limiter, method, base.ResolveReference(rel).String(), body, headers)
+		failed := ctx.Err() == nil && isInstanceFailure(rsp, err)
+		if inst != nil && ctx.Err() == nil && call.sent {
+			pool.report(inst, call.latency, failed)
+		}
+		if breakers != nil {
+			breakers.report(base.Host, isHostFailure(rsp, err), ctx.Err() != nil || !call.sent)
+		}
+
+		// fail over to next instance without backoff.
+		if failover && failed && len(tried) < pool.len() {
+			if logger != nil {
+				logger.InfoContext(ctx, "koios: failing over to next instance",
+					append(attemptAttrs(call, rsp, err), "instance", inst.url.String())...)
+			}
+			discardBody(rsp)
+			continue
+		}
+
+		if !retries || !retry.shouldRetry(ctx, attempt, rsp, err) {
+			return rsp, err
+		}
+		delay, ok := retry.delay(attempt, rsp)
+		if !ok {
+			// server asks to wait longer than policy allows.
+			if logger != nil {
+				logger.InfoContext(ctx, "koios: retry after exceeds policy, not retrying", attemptAttrs(call, rsp, err)...)
+			}
+			return rsp, err
+		}
+		if logger != nil {
+			logger.InfoContext(ctx, "koios: retrying request", append(attemptAttrs(call, rsp, err), "delay", delay)...)
+		}
+		discardBody(rsp)
+		if err := sleepCtx(ctx, delay); err != nil {
+			return nil, err
+		}
+		attempt++
+		tried = make(map[*instance]bool)
+	}
+}
+
+// attempt sends single request through middleware chain.
+func (c *Client) attempt(
+	ctx context.Context,
+	call *Call,
+	limiter Limiter,
+	method string,
+	requrl string,
+	body io.Reader,
+	headers http.Header) (*http.Response, error) {
+	res := call.Response
 	if res != nil {
 		res.RequestURL = requrl
 	}
+	call.sent = false
+	call.latency = 0
+
+	token, err := c.authToken(ctx)
+	if err != nil {
+		closeBody(body)
+		return nil, err
+	}
 
+	c.mux.RLock()
+	slots := c.slots
+	logger := c.logger
 	c.mux.RUnlock()
 
-	// optain lock to update last ts and total
-	// request count. Lock will block is another request is already queued.
-	// e.g. in other go routine.
-	c.mux.Lock()
+	// wait for free slot, waiting is aborted when ctx is done.
+	if slots != nil {
+		wait, err := slots.acquire(ctx, call.Priority)
+		call.queueWait = wait
+		if logger != nil && wait > 0 {
+			logger.DebugContext(ctx, "koios: waited for free slot",
+				"endpoint", call.Endpoint, "priority", call.Priority.String(), "wait", wait)
+		}
+		if err != nil {
+			closeBody(body)
+			return nil, err
+		}
+	}
 
-	// handle rate limit
-	for !c.lastRequest.IsZero() && time.Since(c.lastRequest) < c.reqInterval {
+	// handle rate limit, waiting is aborted when ctx is done.
+	waitStart := time.Now()
+	if err := limiter.Wait(ctx); err != nil {
+		if slots != nil {
+			slots.release()
+		}
+		closeBody(body)
+		return nil, err
+	}
+	if wait := time.Since(waitStart); logger != nil && wait >= time.Millisecond {
+		logger.DebugContext(ctx, "koios: waited for rate limit", "endpoint", call.Endpoint, "wait", wait)
 	}
 
-	c.lastRequest = time.Now()
+	c.mux.Lock()
 	c.totalReq++
-
-	// Release client so that other requests can use it.
+	mws := c.middlewares
 	c.mux.Unlock()
 
-	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(method), requrl, body)
+	req, err := http.NewRequestWithContext(ctx, method, requrl, body)
 	if err != nil {
-		if res != nil {
-			res.applyError(nil, err)
+		if slots != nil {
+			slots.release()
 		}
+		closeBody(body)
 		return nil, err
 	}
 	c.applyReqHeaders(req, headers)
+	if len(token) > 0 && len(req.Header.Get("Authorization")) == 0 {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	call.Request = req
+
+	if logger != nil {
+		logger.DebugContext(ctx, "koios: request",
+			"method", method,
+			"url", requrl,
+			"attempt", call.Attempt,
+			"headers", redactHeaders(req.Header),
+		)
+	}
+	start := time.Now()
+	rsp, err := chain(c.roundTrip, mws)(call)
+	call.latency = time.Since(start)
+	if logger != nil {
+		args := append(attemptAttrs(call, rsp, err), "dur", call.latency)
+		if err != nil {
+			logger.WarnContext(ctx, "koios: request failed", args...)
+		} else {
+			logger.DebugContext(ctx, "koios: response", args...)
+		}
+	}
+	if len(token) > 0 && rsp != nil && rsp.StatusCode == http.StatusUnauthorized {
+		c.authRejected(token)
+	}
+	if slots != nil {
+		if err != nil || rsp == nil {
+			slots.release()
+		} else {
+			rsp.Body = &releaseBody{ReadCloser: rsp.Body, release: slots.release}
+		}
+	}
+	return rsp, err
+}
 
+// roundTrip sends the request of the call.
+func (c *Client) roundTrip(call *Call) (*http.Response, error) {
+	call.sent = true
+	res := call.Response
 	if res != nil && c.reqStatsEnabled {
-		return c.requestWithStats(req, res)
+		return c.requestWithStats(call.Request, res, call.queueWait)
 	}
 
-	rsp, err := c.client.Do(req)
+	rsp, err := c.client.Do(call.Request)
 	if err != nil {
-		if res != nil {
-			res.applyError(nil, err)
-		}
 		return nil, err
 	}
 
 	if res != nil {
 		res.applyRsp(rsp)
 	}
+	decodeResponse(rsp, nil)
 	return rsp, nil
 }
 
+// closeBody closes request body which does not reach the transport,
+// so that goroutine writing it e.g. through io.Pipe is not blocked forever.
+func closeBody(body io.Reader) {
+	if closer, ok := body.(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
+
+// discardBody drains and closes body of response which is not used.
+func discardBody(rsp *http.Response) {
+	if rsp == nil {
+		return
+	}
+	_, _ = io.CopyN(ioutil.Discard, rsp.Body, 4096)
+	_ = rsp.Body.Close()
+}
+
 func (c *Client) applyReqHeaders(req *http.Request, headers http.Header) {
 	req.Header = c.commonHeaders.Clone()
 	if headers != nil {
@@ -166,8 +456,12 @@ func (c *Client) applyReqHeaders(req *http.Request, headers http.Header) {
 	}
 }
 
-func (c *Client) requestWithStats(req *http.Request, res *Response) (*http.Response, error) {
-	res.Stats = &RequestStats{}
+func (c *Client) requestWithStats(
+	req *http.Request,
+	res *Response,
+	queueWait time.Duration,
+) (*http.Response, error) {
+	res.Stats = &RequestStats{QueueWaitDur: queueWait}
 	var dns, tlshs, connect time.Time
 
 	trace := &httptrace.ClientTrace{
@@ -181,18 +475,12 @@ func (c *Client) requestWithStats(req *http.Request, res *Response) (*http.Respo
 			tlshs = time.Now().UTC()
 		},
 		TLSHandshakeDone: func(cs tls.ConnectionState, err error) {
-			if err != nil {
-				res.applyError(nil, err)
-			}
 			res.Stats.TLSHSDur = time.Since(tlshs)
 		},
 		ConnectStart: func(network, addr string) {
 			connect = time.Now().UTC()
 		},
 		ConnectDone: func(network, addr string, err error) {
-			if err != nil {
-				res.applyError(nil, err)
-			}
 			res.Stats.ESTCXNDur = time.Since(connect)
 		},
 		GotFirstResponseByte: func() {
@@ -204,11 +492,11 @@ func (c *Client) requestWithStats(req *http.Request, res *Response) (*http.Respo
 	res.Stats.ReqStartedAt = time.Now().UTC()
 	rsp, err := c.client.Transport.RoundTrip(req)
 	if err != nil {
-		res.applyError(nil, err)
 		return nil, err
 	}
 
 	res.applyRsp(rsp)
+	decodeResponse(rsp, res.Stats)
 	return rsp, nil
 }
 
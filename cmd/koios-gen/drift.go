@@ -0,0 +1,355 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	endpointPath   = regexp.MustCompile(`^/[a-z0-9_]+$`)
+	endpointDoc    = regexp.MustCompile("`/([a-z0-9_]+)`")
+	commentedField = regexp.MustCompile("json:\"([a-z0-9_]+)")
+)
+
+type (
+	// library is hand-written API parsed from the koios package sources.
+	library struct {
+		pkg string
+		// endpoints requested by the package.
+		endpoints map[string]bool
+		// structs declared by the package.
+		structs map[string]*ast.StructType
+		// commented maps structs to json names found in commented out fields.
+		commented map[string]map[string]bool
+		// responses maps endpoints to response types.
+		responses map[string][]string
+		// declared identifiers of types, functions and Client methods.
+		declared map[string]bool
+	}
+
+	// issue is single difference between spec and library.
+	issue struct {
+		endpoint string
+		typ      string
+		msg      string
+	}
+
+	// drift is difference between spec and library.
+	drift struct {
+		// missing endpoints which are in spec but not in library.
+		missing []string
+		// unknown endpoints which are in library but not in spec.
+		unknown []string
+		issues  []issue
+	}
+)
+
+// parseLibrary parses non test Go files in dir.
+func parseLibrary(dir, skip string) (*library, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go") && fi.Name() != skip
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("%s: expected single package got %d", dir, len(pkgs))
+	}
+	lib := &library{
+		endpoints: make(map[string]bool),
+		structs:   make(map[string]*ast.StructType),
+		commented: make(map[string]map[string]bool),
+		responses: make(map[string][]string),
+		declared:  make(map[string]bool),
+	}
+	for name, pkg := range pkgs {
+		lib.pkg = name
+		for _, file := range pkg.Files {
+			lib.inspect(file)
+		}
+	}
+	return lib, nil
+}
+
+func (lib *library) inspect(file *ast.File) {
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			for _, arg := range n.Args {
+				lit, ok := arg.(*ast.BasicLit)
+				if !ok || lit.Kind != token.STRING {
+					continue
+				}
+				if s, err := strconv.Unquote(lit.Value); err == nil && endpointPath.MatchString(s) {
+					lib.endpoints[strings.TrimPrefix(s, "/")] = true
+				}
+			}
+		case *ast.FuncDecl:
+			lib.declared[n.Name.Name] = true
+		case *ast.GenDecl:
+			for _, spec := range n.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					lib.declared[spec.Name.Name] = true
+					st, ok := spec.Type.(*ast.StructType)
+					if !ok {
+						continue
+					}
+					lib.structs[spec.Name.Name] = st
+					lib.commented[spec.Name.Name] = commentedFields(file, st)
+					doc := spec.Doc
+					if doc == nil && len(n.Specs) == 1 {
+						doc = n.Doc
+					}
+					lib.addResponse(spec.Name.Name, st, doc)
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						lib.declared[name.Name] = true
+					}
+				}
+			}
+		}
+		return true
+	})
+}
+
+// addResponse registers response type which embeds Response and has Data field.
+// Endpoint is taken from doc comment e.g. "represents response from `/tip` endpoint"
+// or from type name e.g. TipResponse.
+func (lib *library) addResponse(name string, st *ast.StructType, doc *ast.CommentGroup) {
+	if !strings.HasSuffix(name, "Response") || dataField(st) == nil {
+		return
+	}
+	var ep string
+	if doc != nil {
+		if m := endpointDoc.FindStringSubmatch(doc.Text()); m != nil {
+			ep = m[1]
+		}
+	}
+	if len(ep) == 0 {
+		ep = snakeName(strings.TrimSuffix(name, "Response"))
+	}
+	lib.responses[ep] = append(lib.responses[ep], name)
+}
+
+// compare returns drift between spec endpoints and library.
+func (lib *library) compare(eps []*endpoint) *drift {
+	d := &drift{}
+	inSpec := make(map[string]bool)
+	for _, ep := range eps {
+		inSpec[ep.Name] = true
+		if !lib.endpoints[ep.Name] {
+			d.missing = append(d.missing, ep.Name)
+			continue
+		}
+		for _, typ := range lib.responses[ep.Name] {
+			d.issues = append(d.issues, lib.compareResponse(ep, typ)...)
+		}
+	}
+	for name := range lib.endpoints {
+		if !inSpec[name] {
+			d.unknown = append(d.unknown, name)
+		}
+	}
+	sort.Strings(d.missing)
+	sort.Strings(d.unknown)
+	return d
+}
+
+func (lib *library) compareResponse(ep *endpoint, typ string) []issue {
+	var issues []issue
+	data := dataField(lib.structs[typ])
+	if tag := jsonName(data); tag != "response" {
+		issues = append(issues, issue{ep.Name, typ, fmt.Sprintf("Data field json tag is %q, expected \"response\"", tag)})
+	}
+
+	rows := ep.Result.rows()
+	model := typeName(data.Type)
+	if _, ok := lib.structs[model]; !ok || rows == nil || rows.Type != "object" || len(rows.Properties) == 0 {
+		return issues
+	}
+
+	fields, commented := lib.fields(model)
+	for _, prop := range sortedKeys(rows.Properties) {
+		if fields[prop] {
+			continue
+		}
+		msg := fmt.Sprintf("field %q is missing", prop)
+		if commented[prop] {
+			msg = fmt.Sprintf("field %q is commented out", prop)
+		}
+		issues = append(issues, issue{ep.Name, model, msg})
+	}
+	var extra []string
+	for name := range fields {
+		if _, ok := rows.Properties[name]; !ok {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	for _, name := range extra {
+		issues = append(issues, issue{ep.Name, model, fmt.Sprintf("field %q is not in specification", name)})
+	}
+	return issues
+}
+
+// fields returns json names of struct fields including fields of embedded
+// structs and json names found in commented out fields.
+func (lib *library) fields(model string) (fields, commented map[string]bool) {
+	fields = make(map[string]bool)
+	commented = make(map[string]bool)
+	var walk func(name string, depth int)
+	walk = func(name string, depth int) {
+		for prop := range lib.commented[name] {
+			commented[prop] = true
+		}
+		for _, f := range lib.structs[name].Fields.List {
+			if len(f.Names) == 0 && f.Tag == nil {
+				if emb := typeName(f.Type); lib.structs[emb] != nil && depth < 8 {
+					walk(emb, depth+1)
+				}
+				continue
+			}
+			if prop := jsonName(f); len(prop) > 0 && prop != "-" {
+				fields[prop] = true
+			}
+		}
+	}
+	walk(model, 0)
+	return fields, commented
+}
+
+// commentedFields returns json names found in comments inside of struct.
+func commentedFields(file *ast.File, st *ast.StructType) map[string]bool {
+	names := make(map[string]bool)
+	for _, cg := range file.Comments {
+		if cg.Pos() < st.Fields.Opening || cg.End() > st.Fields.Closing {
+			continue
+		}
+		for _, c := range cg.List {
+			if m := commentedField.FindStringSubmatch(c.Text); m != nil {
+				names[m[1]] = true
+			}
+		}
+	}
+	return names
+}
+
+// empty reports whether there is no drift.
+func (d *drift) empty() bool {
+	return len(d.missing) == 0 && len(d.unknown) == 0 && len(d.issues) == 0
+}
+
+func (d *drift) write(w io.Writer) {
+	if d.empty() {
+		fmt.Fprintln(w, "no drift between specification and library")
+		return
+	}
+	if len(d.missing) > 0 {
+		fmt.Fprintf(w, "endpoints not implemented (%d):\n", len(d.missing))
+		for _, ep := range d.missing {
+			fmt.Fprintf(w, "  /%s\n", ep)
+		}
+	}
+	if len(d.unknown) > 0 {
+		fmt.Fprintf(w, "endpoints not in specification (%d):\n", len(d.unknown))
+		for _, ep := range d.unknown {
+			fmt.Fprintf(w, "  /%s\n", ep)
+		}
+	}
+	if len(d.issues) > 0 {
+		fmt.Fprintf(w, "differences (%d):\n", len(d.issues))
+		for _, is := range d.issues {
+			fmt.Fprintf(w, "  /%s %s: %s\n", is.endpoint, is.typ, is.msg)
+		}
+	}
+}
+
+func dataField(st *ast.StructType) *ast.Field {
+	if st == nil {
+		return nil
+	}
+	for _, f := range st.Fields.List {
+		for _, name := range f.Names {
+			if name.Name == "Data" {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+func jsonName(f *ast.Field) string {
+	if f == nil || f.Tag == nil {
+		return ""
+	}
+	tag, err := strconv.Unquote(f.Tag.Value)
+	if err != nil {
+		return ""
+	}
+	name, _, _ := strings.Cut(reflect.StructTag(tag).Get("json"), ",")
+	return name
+}
+
+// typeName returns name of the type or element type of slice, array or pointer.
+func typeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return typeName(t.X)
+	case *ast.ArrayType:
+		return typeName(t.Elt)
+	}
+	return ""
+}
+
+// snakeName returns snake case name of Go name e.g. EpochParams -> epoch_params.
+func snakeName(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// outputPath returns path of generated file relative to dir unless absolute.
+func outputPath(dir, out string) string {
+	if filepath.IsAbs(out) {
+		return out
+	}
+	return filepath.Join(dir, out)
+}
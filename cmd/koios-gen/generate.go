@@ -0,0 +1,433 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// initialisms are name parts which are not simply title cased.
+var initialisms = map[string]string{
+	"abs":   "Abs",
+	"api":   "API",
+	"cbor":  "Cbor",
+	"dns":   "DNS",
+	"id":    "ID",
+	"ids":   "IDs",
+	"ipv4":  "IPv4",
+	"ipv6":  "IPv6",
+	"json":  "JSON",
+	"srv":   "SRV",
+	"url":   "URL",
+	"utxo":  "UTxO",
+	"utxos": "UTxOs",
+	"vrf":   "Vrf",
+}
+
+// namedTypes maps property and parameter names to types of the koios package.
+var namedTypes = map[string]string{
+	"address":         "Address",
+	"asset_name":      "AssetName",
+	"asset_policy":    "PolicyID",
+	"block_hash":      "BlockHash",
+	"epoch":           "EpochNo",
+	"epoch_no":        "EpochNo",
+	"payment_address": "Address",
+	"payment_cred":    "PaymentCredential",
+	"policy_id":       "PolicyID",
+	"pool_bech32":     "PoolID",
+	"pool_id_bech32":  "PoolID",
+	"script_hash":     "ScriptHash",
+	"stake_address":   "StakeAddress",
+	"tx_hash":         "TxHash",
+}
+
+// namedItemTypes maps names of array properties and parameters
+// to types of the koios package used for the array items.
+var namedItemTypes = map[string]string{
+	"addresses":           "Address",
+	"payment_credentials": "PaymentCredential",
+	"pool_bech32_ids":     "PoolID",
+	"stake_addresses":     "StakeAddress",
+	"tx_hashes":           "TxHash",
+}
+
+type (
+	generator struct {
+		pkg     string
+		header  string
+		imports map[string]bool
+		types   bytes.Buffer
+		methods bytes.Buffer
+		// declared holds identifiers declared by generator and package.
+		declared map[string]bool
+	}
+
+	// field of generated struct or method argument.
+	field struct {
+		name        string
+		jsonName    string
+		typ         string
+		description string
+		required    bool
+	}
+)
+
+func newGenerator(pkg, header string, declared map[string]bool) *generator {
+	g := &generator{
+		pkg:      pkg,
+		header:   header,
+		imports:  map[string]bool{"context": true},
+		declared: make(map[string]bool),
+	}
+	for name := range declared {
+		g.declared[name] = true
+	}
+	return g
+}
+
+// add generates model, response type and Client method of endpoint.
+func (g *generator) add(ep *endpoint) error {
+	name := goName(ep.Name)
+	for _, ident := range []string{name, name + "Response", "Get" + name} {
+		if g.declared[ident] {
+			return fmt.Errorf("%s: %s is already declared", ep.Name, ident)
+		}
+	}
+
+	dataType := "json.RawMessage"
+	if rows := ep.Result.rows(); rows != nil {
+		dataType = g.typeOf(name, ep.Name, rows)
+	}
+	if dataType == "json.RawMessage" {
+		g.imports["encoding/json"] = true
+	}
+	if ep.Result != nil && ep.Result.Type == "array" {
+		dataType = "[]" + dataType
+	}
+
+	fmt.Fprintf(&g.types, "\t// %sResponse represents response from `/%s` endpoint.\n", name, ep.Name)
+	fmt.Fprintf(&g.types, "\t%sResponse struct {\n\t\tResponse\n\t\tData %s `json:\"response\"`\n\t}\n\n", name, dataType)
+	g.declared[name+"Response"] = true
+	g.declared["Get"+name] = true
+
+	g.method(name, ep)
+	return nil
+}
+
+// typeOf returns Go type of schema, object schemas are declared
+// as named struct types.
+func (g *generator) typeOf(name, prop string, sc *schema) string {
+	switch sc.Type {
+	case "string":
+		if t, ok := namedTypes[prop]; ok {
+			return t
+		}
+		return "string"
+	case "integer":
+		if t, ok := namedTypes[prop]; ok && t == "EpochNo" {
+			return t
+		}
+		return "int64"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		if t, ok := namedItemTypes[prop]; ok {
+			return "[]" + t
+		}
+		if sc.Items == nil {
+			g.imports["encoding/json"] = true
+			return "[]json.RawMessage"
+		}
+		return "[]" + g.typeOf(name, prop, sc.Items)
+	case "object":
+		if len(sc.Properties) == 0 {
+			g.imports["encoding/json"] = true
+			return "json.RawMessage"
+		}
+		g.structType(name, sc)
+		return name
+	}
+	g.imports["encoding/json"] = true
+	return "json.RawMessage"
+}
+
+func (g *generator) structType(name string, sc *schema) {
+	if g.declared[name] {
+		return
+	}
+	g.declared[name] = true
+
+	var fields []field
+	for _, prop := range sortedKeys(sc.Properties) {
+		psc := sc.Properties[prop]
+		fname := goName(prop)
+		fields = append(fields, field{
+			name:        fname,
+			jsonName:    prop,
+			typ:         g.typeOf(name+fname, prop, psc),
+			description: psc.Description,
+			required:    contains(sc.Required, prop),
+		})
+	}
+
+	var buf bytes.Buffer
+	if len(sc.Description) > 0 {
+		writeComment(&buf, "\t", name+" "+sc.Description)
+	} else {
+		fmt.Fprintf(&buf, "\t// %s defines model for %s.\n", name, name)
+	}
+	fmt.Fprintf(&buf, "\t%s struct {\n", name)
+	for i, f := range fields {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		if len(f.description) > 0 {
+			writeComment(&buf, "\t\t", f.name+" "+f.description)
+		}
+		omit := ""
+		if !f.required {
+			omit = ",omitempty"
+		}
+		fmt.Fprintf(&buf, "\t\t%s %s `json:\"%s%s\"`\n", f.name, f.typ, f.jsonName, omit)
+	}
+	buf.WriteString("\t}\n\n")
+	g.types.Write(buf.Bytes())
+}
+
+func (g *generator) method(name string, ep *endpoint) {
+	var args []field
+	for _, p := range ep.Params {
+		typ := "string"
+		if p.Schema != nil {
+			typ = g.typeOf(name+goName(p.Name), strings.TrimPrefix(p.Name, "_"), p.Schema)
+		}
+		args = append(args, field{
+			name:     argName(p.Name),
+			jsonName: p.Name,
+			typ:      typ,
+			required: p.Required,
+		})
+	}
+	var payload []field
+	if ep.Body != nil {
+		props := sortedKeys(ep.Body.Properties)
+		// required properties are arguments first.
+		sort.SliceStable(props, func(i, j int) bool {
+			return contains(ep.Body.Required, props[i]) && !contains(ep.Body.Required, props[j])
+		})
+		for _, prop := range props {
+			typ := g.typeOf(name+goName(prop), strings.TrimPrefix(prop, "_"), ep.Body.Properties[prop])
+			f := field{
+				name:     argName(prop),
+				jsonName: prop,
+				typ:      typ,
+				required: contains(ep.Body.Required, prop),
+			}
+			args = append(args, f)
+			payload = append(payload, f)
+		}
+	}
+
+	m := &g.methods
+	summary := strings.TrimSuffix(strings.TrimSpace(ep.Summary), ".")
+	if len(summary) == 0 {
+		summary = "response from `/" + ep.Name + "` endpoint"
+	}
+	writeComment(m, "", fmt.Sprintf("Get%s returns %s.", name, summary))
+	if desc := strings.TrimSpace(ep.Description); len(desc) > 0 && desc != strings.TrimSpace(ep.Summary) {
+		m.WriteString("//\n")
+		writeComment(m, "", desc)
+	}
+	fmt.Fprintf(m, "func (c *Client) Get%s(\n\tctx context.Context,\n", name)
+	for _, a := range args {
+		fmt.Fprintf(m, "\t%s %s,\n", a.name, a.typ)
+	}
+	fmt.Fprintf(m, "\topts ...CallOption,\n) (res *%sResponse, err error) {\n", name)
+	fmt.Fprintf(m, "\tres = &%sResponse{}\n", name)
+
+	query := "nil"
+	if len(ep.Params) > 0 {
+		g.imports["net/url"] = true
+		g.imports["fmt"] = true
+		query = "params"
+		m.WriteString("\tparams := url.Values{}\n")
+		for _, a := range args[:len(ep.Params)] {
+			set := fmt.Sprintf("params.Set(%q, fmt.Sprint(%s))", a.jsonName, a.name)
+			if a.required {
+				fmt.Fprintf(m, "\t%s\n", set)
+				continue
+			}
+			fmt.Fprintf(m, "\tif %s {\n\t\t%s\n\t}\n", zeroCheck(a), set)
+		}
+		m.WriteString("\n")
+	}
+
+	body := "nil"
+	if ep.Method == "POST" {
+		g.imports["io"] = true
+		g.imports["encoding/json"] = true
+		body = "rpipe"
+		m.WriteString("\tvar payload = struct {\n")
+		for _, p := range payload {
+			omit := ""
+			if !p.required {
+				omit = ",omitempty"
+			}
+			fmt.Fprintf(m, "\t\t%s %s `json:\"%s%s\"`\n", goName(p.jsonName), p.typ, p.jsonName, omit)
+		}
+		m.WriteString("\t}{\n")
+		for _, p := range payload {
+			fmt.Fprintf(m, "\t\t%s: %s,\n", goName(p.jsonName), p.name)
+		}
+		m.WriteString("\t}\n\n")
+		m.WriteString("\trpipe, w := io.Pipe()\n\tgo func() {\n\t\tdefer w.Close()\n")
+		m.WriteString("\t\t_ = json.NewEncoder(w).Encode(payload)\n\t}()\n\n")
+	}
+
+	fmt.Fprintf(m, "\trsp, err := c.request(ctx, &res.Response, %q, \"/%s\", %s, %s, nil, opts...)\n",
+		ep.Method, ep.Name, body, query)
+	m.WriteString("\tif err != nil {\n\t\treturn\n\t}\n")
+	m.WriteString("\terr = readAndUnmarshalResponse(rsp, &res.Response, &res.Data)\n\treturn\n}\n\n")
+}
+
+// source returns formatted source of generated file.
+func (g *generator) source() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(g.header)
+	buf.WriteString("// Code generated by koios-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", g.pkg)
+
+	var imports []string
+	for imp := range g.imports {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+	buf.WriteString("import (\n")
+	for _, imp := range imports {
+		fmt.Fprintf(&buf, "\t%q\n", imp)
+	}
+	buf.WriteString(")\n\n")
+
+	if g.types.Len() > 0 {
+		buf.WriteString("type (\n")
+		buf.Write(bytes.TrimRight(g.types.Bytes(), "\n"))
+		buf.WriteString("\n)\n\n")
+	}
+	buf.Write(g.methods.Bytes())
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return buf.Bytes(), err
+	}
+	return src, nil
+}
+
+// goName returns exported Go name of snake case name e.g. pool_id_bech32 -> PoolIDBech32.
+func goName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) {
+		if v, ok := initialisms[strings.ToLower(part)]; ok {
+			b.WriteString(v)
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	res := b.String()
+	if len(res) == 0 || res[0] >= '0' && res[0] <= '9' {
+		res = "X" + res
+	}
+	return res
+}
+
+// argName returns unexported Go name of parameter e.g. _stake_address -> stakeAddress.
+func argName(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+	if len(parts) == 0 {
+		return "arg"
+	}
+	arg := strings.ToLower(parts[0])
+	if len(parts) > 1 {
+		arg += goName(strings.Join(parts[1:], "_"))
+	}
+	if token.IsKeyword(arg) || arg[0] >= '0' && arg[0] <= '9' {
+		arg = "p" + goName(arg)
+	}
+	return arg
+}
+
+func zeroCheck(f field) string {
+	switch {
+	case strings.HasPrefix(f.typ, "[]"), f.typ == "json.RawMessage":
+		return "len(" + f.name + ") > 0"
+	case f.typ == "bool":
+		return f.name
+	case f.typ == "int64", f.typ == "float64", f.typ == "EpochNo":
+		return f.name + " != 0"
+	default:
+		return "len(" + f.name + ") > 0"
+	}
+}
+
+// writeComment writes text as comment wrapped at 80 columns.
+func writeComment(buf *bytes.Buffer, indent, text string) {
+	for _, para := range strings.Split(strings.TrimSpace(text), "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			if len(line) > 0 && len(line)+len(word) > 80 {
+				fmt.Fprintf(buf, "%s// %s\n", indent, line)
+				line = ""
+			}
+			if len(line) > 0 {
+				line += " "
+			}
+			line += word
+		}
+		if len(line) > 0 {
+			fmt.Fprintf(buf, "%s// %s\n", indent, line)
+		}
+	}
+}
+
+func sortedKeys(m map[string]*schema) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
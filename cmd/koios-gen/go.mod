@@ -0,0 +1,5 @@
+module github.com/howijd/koios-rest-go-client/cmd/koios-gen
+
+go 1.18
+
+require gopkg.in/yaml.v3 v3.0.1
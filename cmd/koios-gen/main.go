@@ -0,0 +1,180 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command koios-gen generates models and Client methods of the koios package
+// from Koios OpenAPI specification and reports drift between the specification
+// and hand-written API.
+//
+// Usage:
+//
+//	koios-gen [flags] drift
+//	koios-gen [flags] generate
+//
+// By default generate emits code only for endpoints which are not
+// implemented by the package yet.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// DefaultSpecURL is location of Koios OpenAPI specification.
+const DefaultSpecURL = "https://api.koios.rest/koiosapi.yaml"
+
+// generatedFile is default name of generated file.
+const generatedFile = "generated.go"
+
+const licenseHeader = `// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+`
+
+type config struct {
+	spec      string
+	src       string
+	out       string
+	endpoints string
+	fail      bool
+}
+
+func main() {
+	cfg := config{}
+	flags := flag.NewFlagSet("koios-gen", flag.ExitOnError)
+	flags.StringVar(&cfg.spec, "spec", DefaultSpecURL, "path or url of Koios OpenAPI specification")
+	flags.StringVar(&cfg.src, "src", ".", "directory of the koios package")
+	flags.StringVar(&cfg.out, "out", generatedFile, "generated file relative to -src, - for stdout")
+	flags.StringVar(&cfg.endpoints, "endpoints", "",
+		"comma separated endpoints to generate, defaults to endpoints not implemented by the package")
+	flags.BoolVar(&cfg.fail, "fail", false, "exit with status 1 when drift is found")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: koios-gen [flags] <drift|generate>\n\n")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(os.Args[1:])
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if err := run(cfg, flags.Arg(0), os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "koios-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(cfg config, cmd string, stdout io.Writer) error {
+	s, err := loadSpec(cfg.spec)
+	if err != nil {
+		return err
+	}
+	eps, err := s.endpoints()
+	if err != nil {
+		return err
+	}
+	// previously generated endpoints are generated again.
+	skip := ""
+	if cmd == "generate" && cfg.out != "-" {
+		skip = generatedFile
+	}
+	lib, err := parseLibrary(cfg.src, skip)
+	if err != nil {
+		return err
+	}
+
+	switch cmd {
+	case "drift":
+		d := lib.compare(eps)
+		d.write(stdout)
+		if cfg.fail && !d.empty() {
+			return fmt.Errorf("drift found")
+		}
+		return nil
+	case "generate":
+		return generate(cfg, lib, eps, stdout)
+	}
+	return fmt.Errorf("unknown command %q", cmd)
+}
+
+func generate(cfg config, lib *library, eps []*endpoint, stdout io.Writer) error {
+	selected := make(map[string]bool)
+	if len(cfg.endpoints) > 0 {
+		for _, name := range strings.Split(cfg.endpoints, ",") {
+			selected[strings.Trim(strings.TrimSpace(name), "/")] = true
+		}
+	} else {
+		for _, name := range lib.compare(eps).missing {
+			selected[name] = true
+		}
+	}
+
+	g := newGenerator(lib.pkg, licenseHeader, lib.declared)
+	count := 0
+	for _, ep := range eps {
+		if !selected[ep.Name] {
+			continue
+		}
+		delete(selected, ep.Name)
+		if err := g.add(ep); err != nil {
+			fmt.Fprintln(os.Stderr, "koios-gen: skipped", err)
+			continue
+		}
+		count++
+	}
+	for name := range selected {
+		return fmt.Errorf("endpoint /%s is not in specification", name)
+	}
+
+	src, err := g.source()
+	if err != nil {
+		return err
+	}
+	if cfg.out == "-" {
+		_, err = stdout.Write(src)
+		return err
+	}
+	out := outputPath(cfg.src, cfg.out)
+	if count == 0 {
+		// nothing to generate, remove stale file.
+		if err := os.Remove(out); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		fmt.Fprintln(stdout, "all endpoints are implemented")
+		return nil
+	}
+	//nolint: gosec
+	if err := os.WriteFile(out, src, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "generated %d endpoint(s) to %s\n", count, out)
+	return nil
+}
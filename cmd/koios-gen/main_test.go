@@ -0,0 +1,86 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func testConfig() config {
+	return config{
+		spec: "testdata/koiosapi.yaml",
+		src:  "testdata/lib",
+		out:  "-",
+	}
+}
+
+func TestDrift(t *testing.T) {
+	var out bytes.Buffer
+	cfg := testConfig()
+	cfg.fail = true
+	err := run(cfg, "drift", &out)
+	if err == nil {
+		t.Error("expected drift error")
+	}
+	report := out.String()
+	for _, want := range []string{
+		"/account_txs\n",
+		"/asset_history\n",
+		"endpoints not in specification (1):\n  /totals\n",
+		`/tip TipResponse: Data field json tag is "data", expected "response"`,
+		`/tip Tip: field "block_no" is missing`,
+		`/pool_updates PoolUpdate: field "active_stake" is commented out`,
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report does not contain %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	var out bytes.Buffer
+	if err := run(testConfig(), "generate", &out); err != nil {
+		t.Fatal(err)
+	}
+	src := out.String()
+	if _, err := parser.ParseFile(token.NewFileSet(), "generated.go", src, 0); err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, src)
+	}
+	for _, want := range []string{
+		"// Code generated by koios-gen. DO NOT EDIT.",
+		"func (c *Client) GetAssetHistory(",
+		"assetPolicy PolicyID,",
+		"AssetHistoryMintingTxs struct",
+		"PolicyID PolicyID `json:\"policy_id,omitempty\"`",
+		"func (c *Client) GetAccountTxs(",
+		"stakeAddresses []StakeAddress,",
+		"`json:\"_stake_addresses\"`",
+		"Data []AccountTxs `json:\"response\"`",
+		`c.request(ctx, &res.Response, "POST", "/account_txs", rpipe, nil, nil, opts...)`,
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("generated code does not contain %q:\n%s", want, src)
+		}
+	}
+	if strings.Contains(src, "GetTip") {
+		t.Error("implemented endpoint should not be generated")
+	}
+}
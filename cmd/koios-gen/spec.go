@@ -0,0 +1,315 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// spec is subset of OpenAPI 3 specification used by Koios.
+	spec struct {
+		Paths      map[string]map[string]*operation `yaml:"paths"`
+		Components struct {
+			Schemas       map[string]*schema      `yaml:"schemas"`
+			Parameters    map[string]*parameter   `yaml:"parameters"`
+			RequestBodies map[string]*requestBody `yaml:"requestBodies"`
+			Responses     map[string]*response    `yaml:"responses"`
+		} `yaml:"components"`
+	}
+
+	operation struct {
+		Tags        []string             `yaml:"tags"`
+		Summary     string               `yaml:"summary"`
+		Description string               `yaml:"description"`
+		Parameters  []*parameter         `yaml:"parameters"`
+		RequestBody *requestBody         `yaml:"requestBody"`
+		Responses   map[string]*response `yaml:"responses"`
+	}
+
+	parameter struct {
+		Ref         string  `yaml:"$ref"`
+		Name        string  `yaml:"name"`
+		In          string  `yaml:"in"`
+		Required    bool    `yaml:"required"`
+		Description string  `yaml:"description"`
+		Schema      *schema `yaml:"schema"`
+	}
+
+	requestBody struct {
+		Ref     string               `yaml:"$ref"`
+		Content map[string]mediaType `yaml:"content"`
+	}
+
+	response struct {
+		Ref     string               `yaml:"$ref"`
+		Content map[string]mediaType `yaml:"content"`
+	}
+
+	mediaType struct {
+		Schema *schema `yaml:"schema"`
+	}
+
+	schema struct {
+		Ref         string             `yaml:"$ref"`
+		Type        string             `yaml:"type"`
+		Format      string             `yaml:"format"`
+		Description string             `yaml:"description"`
+		Required    []string           `yaml:"required"`
+		Properties  map[string]*schema `yaml:"properties"`
+		Items       *schema            `yaml:"items"`
+		AllOf       []*schema          `yaml:"allOf"`
+		OneOf       []*schema          `yaml:"oneOf"`
+	}
+
+	// endpoint is resolved spec operation.
+	endpoint struct {
+		Name        string
+		Method      string
+		Summary     string
+		Description string
+		Params      []*parameter
+		Body        *schema
+		Result      *schema
+	}
+)
+
+// loadSpec reads specification from file or http(s) url.
+func loadSpec(location string) (*spec, error) {
+	var r io.ReadCloser
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		rsp, err := http.Get(location) //nolint: gosec
+		if err != nil {
+			return nil, err
+		}
+		if rsp.StatusCode != http.StatusOK {
+			rsp.Body.Close()
+			return nil, fmt.Errorf("%s: %s", location, rsp.Status)
+		}
+		r = rsp.Body
+	} else {
+		f, err := os.Open(location)
+		if err != nil {
+			return nil, err
+		}
+		r = f
+	}
+	defer r.Close()
+	return parseSpec(r)
+}
+
+func parseSpec(r io.Reader) (*spec, error) {
+	s := &spec{}
+	if err := yaml.NewDecoder(r).Decode(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// endpoints returns resolved operations sorted by name.
+func (s *spec) endpoints() ([]*endpoint, error) {
+	var eps []*endpoint
+	for path, ops := range s.Paths {
+		for method, op := range ops {
+			method = strings.ToUpper(method)
+			if method != "GET" && method != "POST" {
+				continue
+			}
+			ep := &endpoint{
+				Name:        strings.Trim(path, "/"),
+				Method:      method,
+				Summary:     op.Summary,
+				Description: op.Description,
+			}
+			for _, p := range op.Parameters {
+				rp, err := s.parameter(p)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", path, err)
+				}
+				if rp.In == "query" {
+					ep.Params = append(ep.Params, rp)
+				}
+			}
+			if op.RequestBody != nil {
+				body, err := s.requestBody(op.RequestBody)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", path, err)
+				}
+				ep.Body = body
+			}
+			if rsp, ok := op.Responses["200"]; ok {
+				res, err := s.response(rsp)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", path, err)
+				}
+				ep.Result = res
+			}
+			eps = append(eps, ep)
+		}
+	}
+	sort.Slice(eps, func(i, j int) bool {
+		if eps[i].Name == eps[j].Name {
+			return eps[i].Method < eps[j].Method
+		}
+		return eps[i].Name < eps[j].Name
+	})
+	return eps, nil
+}
+
+func (s *spec) parameter(p *parameter) (*parameter, error) {
+	if len(p.Ref) == 0 {
+		return p, nil
+	}
+	name := refName(p.Ref, "#/components/parameters/")
+	rp, ok := s.Components.Parameters[name]
+	if !ok {
+		return nil, fmt.Errorf("unresolved parameter %s", p.Ref)
+	}
+	return s.parameter(rp)
+}
+
+func (s *spec) requestBody(b *requestBody) (*schema, error) {
+	if len(b.Ref) > 0 {
+		name := refName(b.Ref, "#/components/requestBodies/")
+		rb, ok := s.Components.RequestBodies[name]
+		if !ok {
+			return nil, fmt.Errorf("unresolved request body %s", b.Ref)
+		}
+		return s.requestBody(rb)
+	}
+	mt, ok := b.Content["application/json"]
+	if !ok || mt.Schema == nil {
+		return nil, nil
+	}
+	return s.resolve(mt.Schema)
+}
+
+func (s *spec) response(r *response) (*schema, error) {
+	if len(r.Ref) > 0 {
+		name := refName(r.Ref, "#/components/responses/")
+		rr, ok := s.Components.Responses[name]
+		if !ok {
+			return nil, fmt.Errorf("unresolved response %s", r.Ref)
+		}
+		return s.response(rr)
+	}
+	mt, ok := r.Content["application/json"]
+	if !ok || mt.Schema == nil {
+		return nil, nil
+	}
+	return s.resolve(mt.Schema)
+}
+
+// resolve returns schema with all references resolved and allOf merged.
+func (s *spec) resolve(sc *schema) (*schema, error) {
+	return s.resolveDepth(sc, 0)
+}
+
+func (s *spec) resolveDepth(sc *schema, depth int) (*schema, error) {
+	if sc == nil {
+		return nil, nil
+	}
+	if depth > 32 {
+		return nil, fmt.Errorf("schema nesting too deep")
+	}
+	if len(sc.Ref) > 0 {
+		name := refName(sc.Ref, "#/components/schemas/")
+		rs, ok := s.Components.Schemas[name]
+		if !ok {
+			return nil, fmt.Errorf("unresolved schema %s", sc.Ref)
+		}
+		res, err := s.resolveDepth(rs, depth+1)
+		if err != nil || res == nil {
+			return res, err
+		}
+		if len(sc.Description) > 0 {
+			cp := *res
+			cp.Description = sc.Description
+			res = &cp
+		}
+		return res, nil
+	}
+
+	res := &schema{
+		Type:        sc.Type,
+		Format:      sc.Format,
+		Description: sc.Description,
+		Required:    sc.Required,
+	}
+	for _, part := range append(sc.AllOf, sc.OneOf...) {
+		rp, err := s.resolveDepth(part, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		if rp == nil {
+			continue
+		}
+		if len(res.Type) == 0 {
+			res.Type = rp.Type
+		}
+		if rp.Items != nil && res.Items == nil {
+			res.Items = rp.Items
+		}
+		for name, prop := range rp.Properties {
+			if res.Properties == nil {
+				res.Properties = make(map[string]*schema)
+			}
+			res.Properties[name] = prop
+		}
+		res.Required = append(res.Required, rp.Required...)
+	}
+	if sc.Items != nil {
+		items, err := s.resolveDepth(sc.Items, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		res.Items = items
+	}
+	for name, prop := range sc.Properties {
+		rp, err := s.resolveDepth(prop, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if res.Properties == nil {
+			res.Properties = make(map[string]*schema)
+		}
+		res.Properties[name] = rp
+	}
+	if len(res.Type) == 0 && len(res.Properties) > 0 {
+		res.Type = "object"
+	}
+	return res, nil
+}
+
+// rows returns schema of single row of the result.
+func (sc *schema) rows() *schema {
+	if sc != nil && sc.Type == "array" && sc.Items != nil {
+		return sc.Items
+	}
+	return sc
+}
+
+func refName(ref, prefix string) string {
+	return strings.TrimPrefix(ref, prefix)
+}
@@ -0,0 +1,45 @@
+package koios
+
+type (
+	Response struct{}
+
+	Tip struct {
+		Hash    string `json:"hash"`
+		EpochNo uint64 `json:"epoch_no"`
+	}
+
+	TipResponse struct {
+		Response
+		Data *Tip `json:"data"`
+	}
+
+	PoolUpdate struct {
+		TxHash string `json:"tx_hash"`
+		ID     string `json:"pool_id_bech32"`
+
+		// // ActiveStake Pool active stake.
+		// ActiveStake string `json:"active_stake"`
+	}
+
+	// PoolUpdatesResponse represents response from `/pool_updates` endpoint.
+	PoolUpdatesResponse struct {
+		Response
+		Data []PoolUpdate `json:"response"`
+	}
+)
+
+type Client struct{}
+
+func (c *Client) request(path string) {}
+
+func (c *Client) GetTip() {
+	c.request("/tip")
+}
+
+func (c *Client) GetPoolUpdates() {
+	c.request("/pool_updates")
+}
+
+func (c *Client) GetTotals() {
+	c.request("/totals")
+}
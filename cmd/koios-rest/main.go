@@ -65,18 +65,13 @@ func main() {
 		Usage:                "CLI Client to consume Koios API https://api.koios.rest",
 		EnableBashCompletion: true,
 		Before: func(c *cli.Context) error {
-
-			if c.Bool("testnet") {
-				handleErr(koios.Host(koios.TestnetHost)(api))
-			} else {
-				handleErr(koios.Host(c.String("host"))(api))
+			cfg, err := loadConfig(c)
+			handleErr(err)
+			opts, err := cfg.Options()
+			handleErr(err)
+			for _, opt := range opts {
+				handleErr(opt(api))
 			}
-			handleErr(koios.APIVersion(c.String("api-version"))(api))
-			handleErr(koios.Port(uint16(c.Uint("port")))(api))
-			handleErr(koios.Schema(c.String("schema"))(api))
-			handleErr(koios.RateLimit(uint8(c.Uint("rate-limit")))(api))
-			handleErr(koios.Origin(c.String("origin"))(api))
-			handleErr(koios.CollectRequestsStats(c.Bool("enable-req-stats"))(api))
 			return nil
 		},
 	}
@@ -156,6 +151,11 @@ func globalFlags() []cli.Flag {
 			Usage: "Set API Client rate limit for outgoing requests",
 			Value: uint(koios.DefaultRateLimit),
 		},
+		&cli.StringFlag{
+			Name:    "auth-token",
+			Usage:   "Set bearer token for Koios authenticated tiers.",
+			EnvVars: []string{"KOIOS_AUTH_TOKEN"},
+		},
 		&cli.BoolFlag{
 			Name:  "no-format",
 			Usage: "prints response json strings directly without calling json pretty.",
@@ -166,12 +166,93 @@ func globalFlags() []cli.Flag {
 			Usage: "Enable request stats.",
 			Value: false,
 		},
+		&cli.StringFlag{
+			Name:  "network",
+			Usage: "Set network profile: mainnet, preprod, preview, guild or testnet",
+		},
 		&cli.BoolFlag{
 			Name:  "testnet",
-			Usage: "use default testnet as host.",
+			Usage: "use default testnet as host (same as --network testnet).",
 			Value: false,
 		},
+		&cli.StringFlag{
+			Name:  "config",
+			Usage: "Load client configuration from YAML, JSON or TOML file.",
+		},
+		&cli.StringFlag{
+			Name:  "profile",
+			Usage: "Set profile of the configuration file or network name.",
+		},
+	}
+}
+
+// loadConfig returns client configuration from config file profile,
+// KOIOS_* environment variables and flags in that order of precedence.
+// Flags --config and --profile take precedence over KOIOS_CONFIG
+// and KOIOS_PROFILE when resolving the profile.
+func loadConfig(c *cli.Context) (koios.Config, error) {
+	path, name := os.Getenv(koios.EnvConfig), os.Getenv(koios.EnvProfile)
+	if c.IsSet("config") {
+		path = c.String("config")
+	}
+	if c.IsSet("profile") {
+		name = c.String("profile")
+	}
+
+	cfg := koios.Config{}
+	if len(path) > 0 {
+		file, err := koios.LoadConfig(path)
+		if err != nil {
+			return cfg, err
+		}
+		if cfg, err = file.Profile(name); err != nil {
+			return cfg, err
+		}
+	} else if len(name) > 0 {
+		if _, ok := koios.LookupNetwork(name); !ok {
+			return cfg, fmt.Errorf("%w: unknown profile %q, configuration file is not set", koios.ErrConfig, name)
+		}
+		cfg.Network = name
+	}
+
+	env, err := koios.ConfigFromEnvVars()
+	if err != nil {
+		return cfg, err
+	}
+	cfg = cfg.Merge(env)
+
+	flags := koios.Config{
+		Network:   c.String("network"),
+		AuthToken: c.String("auth-token"),
+	}
+	if c.Bool("testnet") {
+		flags.Network = koios.Testnet.Name
+	}
+	if c.IsSet("host") {
+		flags.Host = c.String("host")
 	}
+	if c.IsSet("api-version") {
+		flags.APIVersion = c.String("api-version")
+	}
+	if c.IsSet("origin") {
+		flags.Origin = c.String("origin")
+	}
+	if c.IsSet("port") {
+		flags.Port = c.Uint("port")
+	}
+	if c.IsSet("schema") {
+		flags.Schema = c.String("schema")
+	}
+	if c.IsSet("rate-limit") {
+		flags.RateLimit = c.Uint("rate-limit")
+	}
+	if c.IsSet("enable-req-stats") {
+		enabled := c.Bool("enable-req-stats")
+		flags.CollectRequestsStats = &enabled
+	}
+
+	cfg = cfg.Merge(flags)
+	return cfg, cfg.Validate()
 }
 
 func handleErr(err error) {
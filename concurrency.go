@@ -0,0 +1,241 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios
+
+import (
+	"container/list"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Priority classes of requests waiting for free slot when
+// MaxConcurrentRequests is configured. Requests with higher priority
+// are sent first, requests with same priority in FIFO order.
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+
+	numPriorities = 3
+)
+
+type (
+	// Priority of the request in the queue of MaxConcurrentRequests.
+	Priority uint8
+
+	// ConcurrencyStats are metrics of MaxConcurrentRequests queue.
+	ConcurrencyStats struct {
+		// MaxConcurrent is maximum number of requests in flight.
+		MaxConcurrent int `json:"max_concurrent"`
+
+		// InFlight is number of requests currently in flight.
+		InFlight int `json:"in_flight"`
+
+		// Queued is number of requests currently waiting for free slot.
+		Queued int `json:"queued"`
+
+		// QueuedByPriority is number of requests currently waiting
+		// for free slot by priority.
+		QueuedByPriority [numPriorities]int `json:"queued_by_priority"`
+
+		// TotalQueued is total number of requests which had to wait.
+		TotalQueued uint64 `json:"total_queued"`
+
+		// Abandoned is total number of requests which left the queue
+		// because their context was done.
+		Abandoned uint64 `json:"abandoned"`
+
+		// TotalWait is sum of time requests spent in the queue.
+		TotalWait time.Duration `json:"total_wait"`
+
+		// MaxWait is longest time request spent in the queue.
+		MaxWait time.Duration `json:"max_wait"`
+	}
+
+	// concurrencyLimiter limits number of requests in flight.
+	concurrencyLimiter struct {
+		mu     sync.Mutex
+		max    int
+		active int
+		queues [numPriorities]*list.List
+		stats  ConcurrencyStats
+	}
+
+	// releaseBody releases concurrency slot when response body is closed.
+	releaseBody struct {
+		io.ReadCloser
+		once    sync.Once
+		release func()
+	}
+)
+
+// MaxConcurrentRequests limits number of requests in flight to n
+// independently of the rate limit. Slot is held until body of the
+// response is closed. Requests waiting for free slot are queued by
+// their Priority, see WithPriority. Waiting is aborted when context
+// of the request is done.
+func MaxConcurrentRequests(n uint) Option {
+	return func(c *Client) error {
+		if n == 0 {
+			return ErrMaxConcurrentRequests
+		}
+		c.mux.Lock()
+		defer c.mux.Unlock()
+		c.slots = newConcurrencyLimiter(int(n))
+		return nil
+	}
+}
+
+// WithPriority sets priority of the call in the queue of
+// MaxConcurrentRequests. By default /submittx and /tip calls have
+// PriorityHigh, chunks of bulk requests PriorityLow and all other
+// calls PriorityNormal.
+func WithPriority(p Priority) CallOption {
+	return callOptionFunc(func(cfg *callConfig) {
+		if p > PriorityHigh {
+			p = PriorityHigh
+		}
+		cfg.priority = &p
+	})
+}
+
+// ConcurrencyStats returns metrics of MaxConcurrentRequests queue,
+// zero value is returned when concurrency is not limited.
+func (c *Client) ConcurrencyStats() ConcurrencyStats {
+	c.mux.RLock()
+	slots := c.slots
+	c.mux.RUnlock()
+	if slots == nil {
+		return ConcurrencyStats{}
+	}
+	return slots.snapshot()
+}
+
+// String returns name of the priority.
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityNormal:
+		return "normal"
+	case PriorityHigh:
+		return "high"
+	}
+	return "unknown"
+}
+
+// defaultPriority returns priority of the call to path
+// when it was not set with WithPriority.
+func defaultPriority(path string) Priority {
+	switch strings.Trim(path, "/") {
+	case "submittx", "tip":
+		return PriorityHigh
+	}
+	return PriorityNormal
+}
+
+func newConcurrencyLimiter(n int) *concurrencyLimiter {
+	l := &concurrencyLimiter{max: n}
+	for i := range l.queues {
+		l.queues[i] = list.New()
+	}
+	l.stats.MaxConcurrent = n
+	return l
+}
+
+// acquire waits for free slot and returns time spent in the queue.
+func (l *concurrencyLimiter) acquire(ctx context.Context, p Priority) (time.Duration, error) {
+	l.mu.Lock()
+	if l.active < l.max && l.queued() == 0 {
+		l.active++
+		l.mu.Unlock()
+		return 0, nil
+	}
+	ready := make(chan struct{})
+	el := l.queues[p].PushBack(ready)
+	l.stats.TotalQueued++
+	l.mu.Unlock()
+
+	start := time.Now()
+	select {
+	case <-ready:
+		wait := time.Since(start)
+		l.mu.Lock()
+		l.stats.TotalWait += wait
+		if wait > l.stats.MaxWait {
+			l.stats.MaxWait = wait
+		}
+		l.mu.Unlock()
+		return wait, nil
+	case <-ctx.Done():
+	}
+
+	l.mu.Lock()
+	select {
+	case <-ready:
+		// slot was handed over while context was done.
+		l.mu.Unlock()
+		l.release()
+	default:
+		l.queues[p].Remove(el)
+		l.stats.Abandoned++
+		l.mu.Unlock()
+	}
+	return time.Since(start), ctx.Err()
+}
+
+// release hands the slot over to next request in the queue.
+func (l *concurrencyLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for p := numPriorities - 1; p >= 0; p-- {
+		if el := l.queues[p].Front(); el != nil {
+			l.queues[p].Remove(el)
+			close(el.Value.(chan struct{}))
+			return
+		}
+	}
+	l.active--
+}
+
+func (l *concurrencyLimiter) queued() (n int) {
+	for _, q := range l.queues {
+		n += q.Len()
+	}
+	return n
+}
+
+func (l *concurrencyLimiter) snapshot() ConcurrencyStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	stats := l.stats
+	stats.InFlight = l.active
+	for i, q := range l.queues {
+		stats.QueuedByPriority[i] = q.Len()
+		stats.Queued += q.Len()
+	}
+	return stats
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
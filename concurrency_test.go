@@ -0,0 +1,174 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/howijd/koios-rest-go-client"
+)
+
+// blockingHandler blocks requests until release is closed.
+type blockingHandler struct {
+	mu       sync.Mutex
+	inflight int
+	max      int
+	order    []string
+	release  chan struct{}
+}
+
+func (h *blockingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	h.inflight++
+	if h.inflight > h.max {
+		h.max = h.inflight
+	}
+	h.order = append(h.order, r.Header.Get("X-Name"))
+	h.mu.Unlock()
+
+	<-h.release
+
+	h.mu.Lock()
+	h.inflight--
+	h.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte("[]"))
+}
+
+func (h *blockingHandler) serving() (inflight, max int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.inflight, h.max
+}
+
+func waitQueued(t *testing.T, api *koios.Client, n int) {
+	t.Helper()
+	assert.Eventually(t, func() bool {
+		return api.ConcurrencyStats().Queued == n
+	}, time.Second, time.Millisecond)
+}
+
+func TestMaxConcurrentRequests(t *testing.T) {
+	h := &blockingHandler{release: make(chan struct{})}
+	api := newTestClient(t, h, koios.MaxConcurrentRequests(2), koios.CollectRequestsStats(true))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := api.GetAccountList(context.Background())
+			assert.NoError(t, err)
+		}()
+	}
+	waitQueued(t, api, 3)
+	assert.Eventually(t, func() bool {
+		inflight, _ := h.serving()
+		return inflight == 2
+	}, time.Second, time.Millisecond)
+	stats := api.ConcurrencyStats()
+	assert.Equal(t, 2, stats.MaxConcurrent)
+	assert.Equal(t, 2, stats.InFlight)
+	assert.Equal(t, 3, stats.QueuedByPriority[koios.PriorityNormal])
+
+	close(h.release)
+	wg.Wait()
+
+	stats = api.ConcurrencyStats()
+	_, max := h.serving()
+	assert.Equal(t, 2, max)
+	assert.Equal(t, 0, stats.InFlight)
+	assert.Equal(t, 0, stats.Queued)
+	assert.Equal(t, uint64(3), stats.TotalQueued)
+	assert.Greater(t, stats.MaxWait, time.Duration(0))
+	assert.GreaterOrEqual(t, stats.TotalWait, stats.MaxWait)
+
+	_, err := koios.New(koios.MaxConcurrentRequests(0))
+	assert.ErrorIs(t, err, koios.ErrMaxConcurrentRequests)
+}
+
+func TestConcurrencyPriority(t *testing.T) {
+	h := &blockingHandler{release: make(chan struct{})}
+	api := newTestClient(t, h, koios.MaxConcurrentRequests(1))
+
+	var wg sync.WaitGroup
+	call := func(name string, opts ...koios.CallOption) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			opts = append(opts, koios.WithHeader("X-Name", name))
+			_, err := api.GetAccountList(context.Background(), opts...)
+			assert.NoError(t, err)
+		}()
+	}
+
+	call("first")
+	assert.Eventually(t, func() bool {
+		return api.ConcurrencyStats().InFlight == 1
+	}, time.Second, time.Millisecond)
+
+	call("low-1", koios.WithPriority(koios.PriorityLow))
+	waitQueued(t, api, 1)
+	call("normal-1")
+	waitQueued(t, api, 2)
+	call("low-2", koios.WithPriority(koios.PriorityLow))
+	waitQueued(t, api, 3)
+	call("high", koios.WithPriority(koios.PriorityHigh))
+	waitQueued(t, api, 4)
+	call("normal-2")
+	waitQueued(t, api, 5)
+
+	close(h.release)
+	wg.Wait()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	assert.Equal(t, []string{"first", "high", "normal-1", "normal-2", "low-1", "low-2"}, h.order)
+}
+
+func TestConcurrencyAbandon(t *testing.T) {
+	h := &blockingHandler{release: make(chan struct{})}
+	api := newTestClient(t, h, koios.MaxConcurrentRequests(1))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := api.GetAccountList(context.Background())
+		assert.NoError(t, err)
+	}()
+	assert.Eventually(t, func() bool {
+		return api.ConcurrencyStats().InFlight == 1
+	}, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := api.GetAccountList(ctx)
+	assert.ErrorIs(t, err, koios.ErrTimeout)
+
+	stats := api.ConcurrencyStats()
+	assert.Equal(t, uint64(1), stats.Abandoned)
+	assert.Equal(t, 0, stats.Queued)
+
+	close(h.release)
+	<-done
+	assert.Equal(t, 0, api.ConcurrencyStats().InFlight)
+}
@@ -0,0 +1,392 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables read by NewFromEnv and ConfigFromEnv.
+const (
+	EnvConfig                = "KOIOS_CONFIG"
+	EnvProfile               = "KOIOS_PROFILE"
+	EnvNetwork               = "KOIOS_NETWORK"
+	EnvHost                  = "KOIOS_HOST"
+	EnvPort                  = "KOIOS_PORT"
+	EnvSchema                = "KOIOS_SCHEMA"
+	EnvAPIVersion            = "KOIOS_API_VERSION"
+	EnvRateLimit             = "KOIOS_RATE_LIMIT"
+	EnvOrigin                = "KOIOS_ORIGIN"
+	EnvAuthToken             = "KOIOS_AUTH_TOKEN"
+	EnvMaxConcurrentRequests = "KOIOS_MAX_CONCURRENT_REQUESTS"
+	EnvCollectRequestsStats  = "KOIOS_COLLECT_REQUESTS_STATS"
+)
+
+type (
+	// Config is declarative configuration of the API client.
+	// Zero values are not applied and client defaults are used.
+	Config struct {
+		// Network profile e.g. mainnet, preprod, preview, guild or testnet.
+		Network string `json:"network,omitempty" yaml:"network,omitempty" toml:"network,omitempty"`
+
+		// Host overrides host of the network.
+		Host string `json:"host,omitempty" yaml:"host,omitempty" toml:"host,omitempty"`
+
+		// Port of the API.
+		Port uint `json:"port,omitempty" yaml:"port,omitempty" toml:"port,omitempty"`
+
+		// Schema of the API URL, http or https.
+		Schema string `json:"schema,omitempty" yaml:"schema,omitempty" toml:"schema,omitempty"`
+
+		// APIVersion e.g. v0.
+		APIVersion string `json:"api_version,omitempty" yaml:"api_version,omitempty" toml:"api_version,omitempty"`
+
+		// RateLimit in requests per second, 1-255.
+		RateLimit uint `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty" toml:"rate_limit,omitempty"`
+
+		// Origin header of requests.
+		Origin string `json:"origin,omitempty" yaml:"origin,omitempty" toml:"origin,omitempty"`
+
+		// AuthToken is JWT bearer token of authenticated tiers.
+		AuthToken string `json:"auth_token,omitempty" yaml:"auth_token,omitempty" toml:"auth_token,omitempty"`
+
+		// MaxConcurrentRequests limits number of requests in flight.
+		MaxConcurrentRequests uint `json:"max_concurrent_requests,omitempty" yaml:"max_concurrent_requests,omitempty" toml:"max_concurrent_requests,omitempty"` //nolint: lll
+
+		// CollectRequestsStats enables or disables collecting of request
+		// stats, nil leaves it unset so explicit false overrides true.
+		CollectRequestsStats *bool `json:"collect_requests_stats,omitempty" yaml:"collect_requests_stats,omitempty" toml:"collect_requests_stats,omitempty"` //nolint: lll
+	}
+
+	// ConfigFile is configuration file with named profiles. Top level
+	// configuration is shared by all profiles, selected profile
+	// overrides it.
+	ConfigFile struct {
+		Config `yaml:",inline"`
+
+		// DefaultProfile is profile used when profile is not specified.
+		DefaultProfile string `json:"default_profile,omitempty" yaml:"default_profile,omitempty" toml:"default_profile,omitempty"` //nolint: lll
+
+		// Profiles by name e.g. mainnet, preprod, local.
+		Profiles map[string]Config `json:"profiles,omitempty" yaml:"profiles,omitempty" toml:"profiles,omitempty"`
+	}
+)
+
+// NewFromEnv creates API client configured by KOIOS_* environment
+// variables, see ConfigFromEnv. Options are applied after configuration.
+func NewFromEnv(opts ...Option) (*Client, error) {
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return newFromConfig(cfg, opts)
+}
+
+// NewFromConfig creates API client configured by profile of configuration
+// file at path. YAML, JSON and TOML files are supported, format is selected
+// by file extension. When profile is empty default profile of the file is
+// used. Options are applied after configuration.
+func NewFromConfig(path, profile string, opts ...Option) (*Client, error) {
+	file, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := file.Profile(profile)
+	if err != nil {
+		return nil, err
+	}
+	return newFromConfig(cfg, opts)
+}
+
+// ConfigFromEnv returns configuration read from KOIOS_* environment
+// variables. When KOIOS_CONFIG is set configuration file is loaded first
+// with profile KOIOS_PROFILE and environment variables override it.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{}
+	if path := os.Getenv(EnvConfig); len(path) > 0 {
+		file, err := LoadConfig(path)
+		if err != nil {
+			return cfg, err
+		}
+		if cfg, err = file.Profile(os.Getenv(EnvProfile)); err != nil {
+			return cfg, err
+		}
+	} else if name := os.Getenv(EnvProfile); len(name) > 0 {
+		if _, ok := LookupNetwork(name); !ok {
+			return cfg, fmt.Errorf("%w: %s: unknown profile %q, %s is not set", ErrConfig, EnvProfile, name, EnvConfig)
+		}
+		cfg.Network = name
+	}
+
+	env, err := ConfigFromEnvVars()
+	if err != nil {
+		return cfg, err
+	}
+	cfg = cfg.Merge(env)
+	return cfg, cfg.Validate()
+}
+
+// ConfigFromEnvVars returns configuration set by KOIOS_* environment
+// variables other than KOIOS_CONFIG and KOIOS_PROFILE. Configuration
+// file is not loaded and returned configuration is not validated,
+// use it to override configuration resolved by other means.
+func ConfigFromEnvVars() (Config, error) {
+	env := Config{
+		Network:    os.Getenv(EnvNetwork),
+		Host:       os.Getenv(EnvHost),
+		Schema:     os.Getenv(EnvSchema),
+		APIVersion: os.Getenv(EnvAPIVersion),
+		Origin:     os.Getenv(EnvOrigin),
+		AuthToken:  os.Getenv(EnvAuthToken),
+	}
+	for name, dest := range map[string]*uint{
+		EnvPort:                  &env.Port,
+		EnvRateLimit:             &env.RateLimit,
+		EnvMaxConcurrentRequests: &env.MaxConcurrentRequests,
+	} {
+		if v := os.Getenv(name); len(v) > 0 {
+			n, err := strconv.ParseUint(v, 10, 0)
+			if err != nil {
+				return env, fmt.Errorf("%w: %s: %q is not a number", ErrConfig, name, v)
+			}
+			*dest = uint(n)
+		}
+	}
+	if v := os.Getenv(EnvCollectRequestsStats); len(v) > 0 {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return env, fmt.Errorf("%w: %s: %q is not a boolean", ErrConfig, EnvCollectRequestsStats, v)
+		}
+		env.CollectRequestsStats = &enabled
+	}
+	return env, nil
+}
+
+// LoadConfig reads configuration file at path. YAML, JSON and TOML
+// files are supported, format is selected by file extension.
+// Unknown keys are reported as error.
+func LoadConfig(path string) (*ConfigFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrConfig, err.Error())
+	}
+	file := &ConfigFile{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(file)
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(file)
+	case ".toml":
+		var md toml.MetaData
+		md, err = toml.Decode(string(data), file)
+		if err == nil {
+			if undecoded := md.Undecoded(); len(undecoded) > 0 {
+				err = fmt.Errorf("unknown key %q", undecoded[0].String())
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%s: %w: unsupported format %q", path, ErrConfig, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", path, ErrConfig, err.Error())
+	}
+	if err := file.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return file, nil
+}
+
+// Profile returns configuration of profile name merged with top level
+// configuration. When name is empty default profile is used, when there
+// is no default profile top level configuration is returned. Names of
+// predefined networks can be used as profiles even when they are
+// not defined by the file.
+func (f *ConfigFile) Profile(name string) (Config, error) {
+	if len(name) == 0 {
+		name = f.DefaultProfile
+	}
+	if len(name) == 0 {
+		return f.Config, nil
+	}
+	if p, ok := f.Profiles[name]; ok {
+		return f.Config.Merge(p), nil
+	}
+	if _, ok := LookupNetwork(name); ok {
+		return f.Config.Merge(Config{Network: name}), nil
+	}
+	names := make([]string, 0, len(f.Profiles))
+	for n := range f.Profiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return Config{}, fmt.Errorf("%w: unknown profile %q, available profiles: %s",
+		ErrConfig, name, strings.Join(names, ", "))
+}
+
+// Validate validates top level configuration and all profiles.
+func (f *ConfigFile) Validate() error {
+	if err := f.Config.Validate(); err != nil {
+		return err
+	}
+	for name, p := range f.Profiles {
+		if err := f.Config.Merge(p).Validate(); err != nil {
+			return fmt.Errorf("profile %q: %w", name, err)
+		}
+	}
+	if len(f.DefaultProfile) > 0 {
+		if _, err := f.Profile(f.DefaultProfile); err != nil {
+			return fmt.Errorf("default_profile: %w", err)
+		}
+	}
+	return nil
+}
+
+// Merge returns copy of cfg overridden by non zero values of o.
+// Booleans set in o override cfg, including explicit false.
+func (cfg Config) Merge(o Config) Config {
+	if len(o.Network) > 0 {
+		cfg.Network = o.Network
+	}
+	if len(o.Host) > 0 {
+		cfg.Host = o.Host
+	}
+	if o.Port > 0 {
+		cfg.Port = o.Port
+	}
+	if len(o.Schema) > 0 {
+		cfg.Schema = o.Schema
+	}
+	if len(o.APIVersion) > 0 {
+		cfg.APIVersion = o.APIVersion
+	}
+	if o.RateLimit > 0 {
+		cfg.RateLimit = o.RateLimit
+	}
+	if len(o.Origin) > 0 {
+		cfg.Origin = o.Origin
+	}
+	if len(o.AuthToken) > 0 {
+		cfg.AuthToken = o.AuthToken
+	}
+	if o.MaxConcurrentRequests > 0 {
+		cfg.MaxConcurrentRequests = o.MaxConcurrentRequests
+	}
+	if o.CollectRequestsStats != nil {
+		enabled := *o.CollectRequestsStats
+		cfg.CollectRequestsStats = &enabled
+	}
+	return cfg
+}
+
+// Validate reports first invalid value of the configuration.
+func (cfg Config) Validate() error {
+	if len(cfg.Network) > 0 {
+		if _, ok := LookupNetwork(cfg.Network); !ok {
+			return fmt.Errorf("%w: network: unknown network %q", ErrConfig, cfg.Network)
+		}
+	}
+	if strings.ContainsAny(cfg.Host, "/:") {
+		return fmt.Errorf("%w: host: %q must be hostname without schema, port or path", ErrConfig, cfg.Host)
+	}
+	if cfg.Port > 65535 {
+		return fmt.Errorf("%w: port: %d is out of range 1-65535", ErrConfig, cfg.Port)
+	}
+	if len(cfg.Schema) > 0 && cfg.Schema != "http" && cfg.Schema != "https" {
+		return fmt.Errorf("%w: schema: %q must be http or https", ErrConfig, cfg.Schema)
+	}
+	if cfg.RateLimit > 255 {
+		return fmt.Errorf("%w: rate_limit: %d is out of range 1-255", ErrConfig, cfg.RateLimit)
+	}
+	if len(cfg.Origin) > 0 {
+		if _, err := url.ParseRequestURI(cfg.Origin); err != nil {
+			return fmt.Errorf("%w: origin: %q is not valid URL", ErrConfig, cfg.Origin)
+		}
+	}
+	if len(cfg.AuthToken) > 0 {
+		if _, err := parseToken(cfg.AuthToken); err != nil {
+			return fmt.Errorf("%w: auth_token: %s", ErrConfig, err.Error())
+		}
+	}
+	return nil
+}
+
+// Options returns options configuring API client according to cfg.
+func (cfg Config) Options() ([]Option, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	var opts []Option
+	if len(cfg.Network) > 0 {
+		n, _ := LookupNetwork(cfg.Network)
+		// custom host e.g. local instance serving the network.
+		if len(cfg.Host) > 0 {
+			n.Host = cfg.Host
+		}
+		opts = append(opts, UseNetwork(n))
+	} else if len(cfg.Host) > 0 {
+		opts = append(opts, Host(cfg.Host))
+	}
+	if len(cfg.APIVersion) > 0 {
+		opts = append(opts, APIVersion(cfg.APIVersion))
+	}
+	if cfg.Port > 0 {
+		opts = append(opts, Port(uint16(cfg.Port)))
+	}
+	if len(cfg.Schema) > 0 {
+		opts = append(opts, Schema(cfg.Schema))
+	}
+	if cfg.RateLimit > 0 {
+		opts = append(opts, RateLimit(uint8(cfg.RateLimit)))
+	}
+	if len(cfg.Origin) > 0 {
+		opts = append(opts, Origin(cfg.Origin))
+	}
+	if len(cfg.AuthToken) > 0 {
+		opts = append(opts, Auth(cfg.AuthToken))
+	}
+	if cfg.MaxConcurrentRequests > 0 {
+		opts = append(opts, MaxConcurrentRequests(cfg.MaxConcurrentRequests))
+	}
+	if cfg.CollectRequestsStats != nil {
+		opts = append(opts, CollectRequestsStats(*cfg.CollectRequestsStats))
+	}
+	return opts, nil
+}
+
+func newFromConfig(cfg Config, opts []Option) (*Client, error) {
+	cfgOpts, err := cfg.Options()
+	if err != nil {
+		return nil, err
+	}
+	return New(append(cfgOpts, opts...)...)
+}
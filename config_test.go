@@ -0,0 +1,247 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/howijd/koios-rest-go-client"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+var configFiles = map[string]string{
+	"koios.yaml": `
+origin: https://example.com
+rate_limit: 10
+default_profile: preprod
+profiles:
+  preprod:
+    network: preprod
+  local:
+    network: preprod
+    host: localhost
+    port: 8080
+    schema: http
+    rate_limit: 100
+    collect_requests_stats: true
+`,
+	"koios.json": `{
+  "origin": "https://example.com",
+  "rate_limit": 10,
+  "default_profile": "preprod",
+  "profiles": {
+    "preprod": {"network": "preprod"},
+    "local": {
+      "network": "preprod",
+      "host": "localhost",
+      "port": 8080,
+      "schema": "http",
+      "rate_limit": 100,
+      "collect_requests_stats": true
+    }
+  }
+}`,
+	"koios.toml": `
+origin = "https://example.com"
+rate_limit = 10
+default_profile = "preprod"
+
+[profiles.preprod]
+network = "preprod"
+
+[profiles.local]
+network = "preprod"
+host = "localhost"
+port = 8080
+schema = "http"
+rate_limit = 100
+collect_requests_stats = true
+`,
+}
+
+func TestLoadConfig(t *testing.T) {
+	for name, content := range configFiles {
+		t.Run(name, func(t *testing.T) {
+			file, err := koios.LoadConfig(writeConfig(t, name, content))
+			assert.NoError(t, err)
+
+			cfg, err := file.Profile("")
+			assert.NoError(t, err)
+			assert.Equal(t, koios.Config{
+				Network:   "preprod",
+				Origin:    "https://example.com",
+				RateLimit: 10,
+			}, cfg)
+
+			cfg, err = file.Profile("local")
+			assert.NoError(t, err)
+			assert.Equal(t, koios.Config{
+				Network:              "preprod",
+				Host:                 "localhost",
+				Port:                 8080,
+				Schema:               "http",
+				Origin:               "https://example.com",
+				RateLimit:            100,
+				CollectRequestsStats: boolPtr(true),
+			}, cfg)
+
+			cfg, err = file.Profile("mainnet")
+			assert.NoError(t, err)
+			assert.Equal(t, "mainnet", cfg.Network)
+
+			_, err = file.Profile("staging")
+			assert.ErrorIs(t, err, koios.ErrConfig)
+			assert.Contains(t, err.Error(), "available profiles: local, preprod")
+		})
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := map[string]string{
+		"unknown.yaml":    "rate_limt: 10\n",
+		"unknown.json":    `{"profiles": {"local": {"hots": "localhost"}}}`,
+		"unknown.toml":    "[profiles.local]\nhots = \"localhost\"\n",
+		"network.yaml":    "network: moon\n",
+		"port.yaml":       "profiles:\n  local:\n    port: 70000\n",
+		"schema.yaml":     "schema: ftp\n",
+		"rate.toml":       "rate_limit = 300\n",
+		"host.json":       `{"host": "https://localhost:8080"}`,
+		"default.yaml":    "default_profile: staging\n",
+		"token.yaml":      "auth_token: secret\n",
+		"koios.ini":       "host=localhost\n",
+		"malformed.json":  `{"host": `,
+		"origin.toml":     "origin = \"example\"\n",
+		"malformed.yaml":  "host: [\n",
+		"malformed2.toml": "host = \n",
+	}
+	for name, content := range tests {
+		_, err := koios.LoadConfig(writeConfig(t, name, content))
+		assert.ErrorIs(t, err, koios.ErrConfig, name)
+	}
+	_, err := koios.LoadConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorIs(t, err, koios.ErrConfig)
+}
+
+func TestNewFromConfig(t *testing.T) {
+	path := writeConfig(t, "koios.yaml", configFiles["koios.yaml"])
+
+	api, err := koios.NewFromConfig(path, "local")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/api/v0/", api.BaseURL())
+	n, ok := api.Network()
+	assert.True(t, ok)
+	assert.Equal(t, "preprod", n.Name)
+
+	api, err = koios.NewFromConfig(path, "", koios.Host("localhost"))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://localhost/api/v0/", api.BaseURL())
+
+	_, err = koios.NewFromConfig(path, "staging")
+	assert.ErrorIs(t, err, koios.ErrConfig)
+}
+
+func TestNewFromEnv(t *testing.T) {
+	t.Setenv(koios.EnvConfig, writeConfig(t, "koios.toml", configFiles["koios.toml"]))
+	t.Setenv(koios.EnvProfile, "local")
+	t.Setenv(koios.EnvPort, "8081")
+
+	api, err := koios.NewFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost:8081/api/v0/", api.BaseURL())
+
+	cfg, err := koios.ConfigFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, uint(100), cfg.RateLimit)
+
+	t.Setenv(koios.EnvConfig, "")
+	t.Setenv(koios.EnvProfile, "preview")
+	t.Setenv(koios.EnvPort, "")
+	api, err = koios.NewFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, "https://preview.koios.rest/api/v0/", api.BaseURL())
+
+	t.Setenv(koios.EnvProfile, "local")
+	_, err = koios.NewFromEnv()
+	assert.ErrorIs(t, err, koios.ErrConfig)
+
+	t.Setenv(koios.EnvProfile, "")
+	t.Setenv(koios.EnvRateLimit, "fast")
+	_, err = koios.NewFromEnv()
+	assert.ErrorIs(t, err, koios.ErrConfig)
+
+	t.Setenv(koios.EnvRateLimit, "256")
+	_, err = koios.NewFromEnv()
+	assert.ErrorIs(t, err, koios.ErrConfig)
+
+	t.Setenv(koios.EnvRateLimit, "")
+	t.Setenv(koios.EnvCollectRequestsStats, "maybe")
+	_, err = koios.NewFromEnv()
+	assert.ErrorIs(t, err, koios.ErrConfig)
+}
+
+func TestConfigFromEnvVars(t *testing.T) {
+	// profile is resolved by caller e.g. from --config flag of CLI.
+	t.Setenv(koios.EnvConfig, "")
+	t.Setenv(koios.EnvProfile, "local")
+	t.Setenv(koios.EnvPort, "8081")
+	t.Setenv(koios.EnvCollectRequestsStats, "true")
+
+	env, err := koios.ConfigFromEnvVars()
+	assert.NoError(t, err)
+	assert.Equal(t, koios.Config{Port: 8081, CollectRequestsStats: boolPtr(true)}, env)
+
+	file, err := koios.LoadConfig(writeConfig(t, "koios.toml", configFiles["koios.toml"]))
+	assert.NoError(t, err)
+	profile, err := file.Profile("local")
+	assert.NoError(t, err)
+	cfg := profile.Merge(env)
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, uint(8081), cfg.Port)
+	assert.Equal(t, uint(100), cfg.RateLimit)
+
+	// explicit false overrides true of the profile.
+	t.Setenv(koios.EnvCollectRequestsStats, "false")
+	env, err = koios.ConfigFromEnvVars()
+	assert.NoError(t, err)
+	cfg = profile.Merge(env)
+	if assert.NotNil(t, cfg.CollectRequestsStats) {
+		assert.False(t, *cfg.CollectRequestsStats)
+	}
+
+	t.Setenv(koios.EnvCollectRequestsStats, "")
+	env, err = koios.ConfigFromEnvVars()
+	assert.NoError(t, err)
+	assert.Equal(t, boolPtr(true), profile.Merge(env).CollectRequestsStats)
+
+	t.Setenv(koios.EnvPort, "port")
+	_, err = koios.ConfigFromEnvVars()
+	assert.ErrorIs(t, err, koios.ErrConfig)
+}
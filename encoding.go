@@ -0,0 +1,193 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Content encodings supported by the client.
+const (
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+	EncodingBrotli  = "br"
+)
+
+// DefaultAcceptEncoding is default value of Accept-Encoding header.
+var DefaultAcceptEncoding = []string{EncodingGzip, EncodingDeflate, EncodingBrotli}
+
+type (
+	// decoderFunc returns reader decompressing r.
+	decoderFunc func(r io.Reader) (io.ReadCloser, error)
+
+	// decodingBody decodes response body according to Content-Encoding
+	// and counts compressed and decompressed bytes. Decoder is created
+	// on first read so that empty bodies e.g. of HEAD requests are
+	// not treated as corrupt.
+	decodingBody struct {
+		body      io.ReadCloser
+		encodings []string
+		raw       *countingReader
+		r         io.Reader
+		n         int64
+		closers   []io.Closer
+		stats     *RequestStats
+		err       error
+	}
+)
+
+// decoders of supported content encodings.
+var decoders = map[string]decoderFunc{
+	EncodingGzip: func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	EncodingDeflate: newDeflateReader,
+	EncodingBrotli: func(r io.Reader) (io.ReadCloser, error) {
+		return ioutil.NopCloser(brotli.NewReader(r)), nil
+	},
+}
+
+// AcceptEncoding sets content encodings accepted by the client,
+// responses are decompressed according to Content-Encoding header.
+// Supported encodings are gzip, deflate and br (brotli), by default
+// all of them are accepted. Calling it without encodings disables
+// compression of responses.
+func AcceptEncoding(encodings ...string) Option {
+	return func(c *Client) error {
+		for _, enc := range encodings {
+			if _, ok := decoders[enc]; !ok {
+				return fmt.Errorf("%w: %s", ErrUnsupportedEncoding, enc)
+			}
+		}
+		c.mux.Lock()
+		defer c.mux.Unlock()
+		if len(encodings) == 0 {
+			// identity prevents transport from requesting gzip on its own.
+			c.commonHeaders.Set("Accept-Encoding", "identity")
+			return nil
+		}
+		c.commonHeaders.Set("Accept-Encoding", strings.Join(encodings, ", "))
+		return nil
+	}
+}
+
+// decodeResponse replaces body of rsp with decoding reader when response
+// is compressed. Byte counters are reported to stats when not nil.
+func decodeResponse(rsp *http.Response, stats *RequestStats) {
+	var encodings []string
+	for _, enc := range strings.Split(rsp.Header.Get("Content-Encoding"), ",") {
+		enc = strings.ToLower(strings.TrimSpace(enc))
+		if len(enc) > 0 && enc != "identity" {
+			encodings = append(encodings, enc)
+		}
+	}
+
+	if stats != nil {
+		stats.ContentEncoding = strings.Join(encodings, ", ")
+	}
+	rsp.Body = &decodingBody{
+		body:      rsp.Body,
+		encodings: encodings,
+		raw:       &countingReader{r: rsp.Body},
+		stats:     stats,
+	}
+
+	if len(encodings) > 0 {
+		rsp.Header.Del("Content-Encoding")
+		rsp.Header.Del("Content-Length")
+		rsp.ContentLength = -1
+		rsp.Uncompressed = true
+	}
+}
+
+// Read implements io.Reader.
+func (b *decodingBody) Read(p []byte) (int, error) {
+	if b.r == nil && b.err == nil {
+		b.err = b.init()
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	n, err := b.r.Read(p)
+	b.n += int64(n)
+	if b.stats != nil {
+		b.stats.BytesCompressed = b.raw.n
+		b.stats.BytesDecompressed = b.n
+	}
+	return n, err
+}
+
+// Close implements io.Closer.
+func (b *decodingBody) Close() error {
+	for _, c := range b.closers {
+		_ = c.Close()
+	}
+	return b.body.Close()
+}
+
+func (b *decodingBody) init() error {
+	var r io.Reader = b.raw
+	// encodings are listed in the order in which they were applied.
+	for i := len(b.encodings) - 1; i >= 0; i-- {
+		decode, ok := decoders[b.encodings[i]]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnsupportedEncoding, b.encodings[i])
+		}
+		dec, err := decode(r)
+		if errors.Is(err, io.EOF) && i == len(b.encodings)-1 {
+			// empty body.
+			b.r = r
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", b.encodings[i], err)
+		}
+		b.closers = append(b.closers, dec)
+		r = dec
+	}
+	b.r = r
+	return nil
+}
+
+// newDeflateReader returns reader for deflate encoding. Per RFC 9110
+// deflate is zlib stream, but some servers send raw deflate data.
+func newDeflateReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	hdr, err := br.Peek(2)
+	if err != nil {
+		if errors.Is(err, io.EOF) && len(hdr) == 0 {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	// zlib header: compression method 8 and header checksum.
+	if hdr[0]&0x0f == 8 && (uint16(hdr[0])<<8|uint16(hdr[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
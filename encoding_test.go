@@ -0,0 +1,142 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/howijd/koios-rest-go-client"
+)
+
+func compress(t *testing.T, encoding string, data []byte) []byte {
+	t.Helper()
+	var (
+		buf bytes.Buffer
+		w   io.WriteCloser
+		err error
+	)
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "raw-deflate":
+		w, err = flate.NewWriter(&buf, flate.DefaultCompression)
+		assert.NoError(t, err)
+	case "br":
+		w = brotli.NewWriter(&buf)
+	default:
+		return data
+	}
+	_, err = w.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestContentEncoding(t *testing.T) {
+	var accounts []string
+	for i := 0; i < 100; i++ {
+		accounts = append(accounts, fmt.Sprintf(`{"id":"stake1u%054d"}`, i))
+	}
+	payload := []byte("[" + strings.Join(accounts, ",") + "]")
+
+	for _, encoding := range []string{"", "gzip", "deflate", "raw-deflate", "br"} {
+		encoding := encoding
+		t.Run("encoding="+encoding, func(t *testing.T) {
+			var accepted string
+			api := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				accepted = r.Header.Get("Accept-Encoding")
+				w.Header().Set("Content-Type", "application/json")
+				if len(encoding) > 0 {
+					w.Header().Set("Content-Encoding", strings.TrimPrefix(encoding, "raw-"))
+				}
+				_, _ = w.Write(compress(t, encoding, payload))
+			}), koios.CollectRequestsStats(true))
+
+			res, err := api.GetAccountList(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, "gzip, deflate, br", accepted)
+			assert.Len(t, res.Data, 100)
+			assert.Equal(t, int64(len(payload)), res.Stats.BytesDecompressed)
+			assert.Equal(t, int64(len(compress(t, encoding, payload))), res.Stats.BytesCompressed)
+			if len(encoding) > 0 {
+				assert.Less(t, res.Stats.BytesCompressed, res.Stats.BytesDecompressed)
+				assert.Equal(t, strings.TrimPrefix(encoding, "raw-"), res.Stats.ContentEncoding)
+			}
+		})
+	}
+}
+
+func TestContentEncodingEmptyBody(t *testing.T) {
+	api := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+	}))
+
+	rsp, err := api.HEAD(context.Background(), "tip", nil, nil)
+	assert.NoError(t, err)
+	body, err := ioutil.ReadAll(rsp.Body)
+	assert.NoError(t, err)
+	assert.Empty(t, body)
+	assert.NoError(t, rsp.Body.Close())
+}
+
+func TestUnsupportedContentEncoding(t *testing.T) {
+	api := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "zstd")
+		_, _ = w.Write([]byte("[]"))
+	}))
+
+	_, err := api.GetAccountList(context.Background())
+	assert.ErrorIs(t, err, koios.ErrUnsupportedEncoding)
+}
+
+func TestAcceptEncoding(t *testing.T) {
+	var accepted string
+	api := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accepted = r.Header.Get("Accept-Encoding")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("[]"))
+	}), koios.AcceptEncoding("br"))
+
+	_, err := api.GetAccountList(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "br", accepted)
+
+	assert.NoError(t, koios.AcceptEncoding()(api))
+	_, err = api.GetAccountList(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "identity", accepted)
+
+	_, err = koios.New(koios.AcceptEncoding("gzip", "zstd"))
+	assert.ErrorIs(t, err, koios.ErrUnsupportedEncoding)
+}
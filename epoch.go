@@ -18,7 +18,6 @@ package koios
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/url"
 )
@@ -167,58 +166,59 @@ type (
 )
 
 // GetEpochInfo returns the epoch information, all epochs if no epoch specified.
-func (c *Client) GetEpochInfo(ctx context.Context, epoch *EpochNo) (res *EpochInfoResponse, err error) {
+func (c *Client) GetEpochInfo(
+	ctx context.Context,
+	epoch *EpochNo,
+	opts ...CallOption,
+) (res *EpochInfoResponse, err error) {
 	res = &EpochInfoResponse{}
 	params := url.Values{}
 	if epoch != nil {
 		params.Set("_epoch_no", fmt.Sprint(*epoch))
 	}
 
-	rsp, err := c.request(ctx, &res.Response, "GET", "/epoch_info", nil, params, nil)
+	rsp, err := c.request(ctx, &res.Response, "GET", "/epoch_info", nil, params, nil, opts...)
 	if err != nil {
-		res.applyError(nil, err)
 		return
 	}
 
-	body, err := readResponseBody(rsp)
-	if err != nil {
-		res.applyError(body, err)
-		return
-	}
-
-	if err = json.Unmarshal(body, &res.Data); err != nil {
-		res.applyError(body, err)
-		return
-	}
-	res.ready()
+	err = readAndUnmarshalResponse(rsp, &res.Response, &res.Data)
 	return
 }
 
 // GetEpochParams returns the protocol parameters for specific epoch,
 // and information about all epochs if no epoch specified.
-func (c *Client) GetEpochParams(ctx context.Context, epoch *EpochNo) (res *EpochParamsResponse, err error) {
+func (c *Client) GetEpochParams(
+	ctx context.Context,
+	epoch *EpochNo,
+	opts ...CallOption,
+) (res *EpochParamsResponse, err error) {
 	res = &EpochParamsResponse{}
 	params := url.Values{}
 	if epoch != nil {
 		params.Set("_epoch_no", fmt.Sprint(*epoch))
 	}
 
-	rsp, err := c.request(ctx, &res.Response, "GET", "/epoch_params", nil, params, nil)
-	if err != nil {
-		res.applyError(nil, err)
+	// parameters of past epochs never change.
+	rc := c.responseCache(opts)
+	if rc != nil && epoch != nil &&
+		rc.get(rc.key("epoch_params", fmt.Sprint(*epoch)), &res.Data) {
+		c.applyCached(ctx, rc, &res.Response, "GET", "epoch_params", params)
 		return
 	}
 
-	body, err := readResponseBody(rsp)
+	rsp, err := c.request(ctx, &res.Response, "GET", "/epoch_params", nil, params, nil, opts...)
 	if err != nil {
-		res.applyError(body, err)
 		return
 	}
 
-	if err = json.Unmarshal(body, &res.Data); err != nil {
-		res.applyError(body, err)
+	if err = readAndUnmarshalResponse(rsp, &res.Response, &res.Data); err != nil {
 		return
 	}
-	res.ready()
+	if rc != nil && epoch != nil && len(res.Data) > 0 {
+		if tip, _ := c.cachedTip(ctx, rc); rc.isPastEpoch(tip, *epoch) {
+			rc.set(rc.key("epoch_params", fmt.Sprint(*epoch)), res.Data)
+		}
+	}
 	return
 }
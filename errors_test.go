@@ -0,0 +1,82 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/howijd/koios-rest-go-client"
+)
+
+func TestResponseErrorClasses(t *testing.T) {
+	tests := []struct {
+		status int
+		class  error
+	}{
+		{http.StatusNotFound, koios.ErrNotFound},
+		{http.StatusTooManyRequests, koios.ErrRateLimited},
+		{http.StatusServiceUnavailable, koios.ErrServerUnavailable},
+		{http.StatusBadRequest, koios.ErrBadRequest},
+		{http.StatusGatewayTimeout, koios.ErrTimeout},
+	}
+	for _, tt := range tests {
+		status := tt.status
+		api := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"code":"PGRST","message":"failed","hint":"check input"}`))
+		}))
+
+		res, err := api.GetAccountRewards(context.Background(),
+			"stake1u8yxtugdv63wxafy9d00nuz6hjyyp4qnggvc9a3vxh8yl0ckml2uz", nil)
+		assert.ErrorIs(t, err, tt.class, http.StatusText(status))
+		assert.NotNil(t, res.Error)
+
+		var rerr *koios.ResponseError
+		if assert.True(t, errors.As(err, &rerr)) {
+			assert.Equal(t, status, rerr.StatusCode)
+			assert.Equal(t, "PGRST", rerr.Code)
+			assert.Equal(t, "check input", rerr.Hint)
+		}
+	}
+}
+
+func TestResponseErrorTimeout(t *testing.T) {
+	api := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := api.GetTip(ctx)
+	assert.ErrorIs(t, err, koios.ErrTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestResponseErrorValidation(t *testing.T) {
+	api, err := koios.New()
+	assert.NoError(t, err)
+	res, err := api.GetTxsInfos(context.Background(), nil)
+	assert.ErrorIs(t, err, koios.ErrNoTxHash)
+	assert.Equal(t, res.Error, err)
+}
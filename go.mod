@@ -5,13 +5,15 @@ go 1.18
 replace github.com/shopspring/decimal => github.com/howijd/decimal v1.3.1
 
 require (
+	github.com/BurntSushi/toml v1.2.0
+	github.com/andybalholm/brotli v1.0.4
 	github.com/shopspring/decimal v1.3.1
 	github.com/stretchr/testify v1.7.0
 	golang.org/x/text v0.3.7
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
 )
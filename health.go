@@ -0,0 +1,251 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxTipLag is maximum age of the chain tip of healthy instance.
+const DefaultMaxTipLag = 3 * time.Minute
+
+type (
+	// HealthReport is result of the health check.
+	HealthReport struct {
+		// Healthy is true when at least one of checked hosts is healthy.
+		Healthy bool `json:"healthy"`
+
+		// CheckedAt is time of the check.
+		CheckedAt time.Time `json:"checked_at"`
+
+		// MaxTipLag used to evaluate health of hosts.
+		MaxTipLag time.Duration `json:"max_tip_lag"`
+
+		// SlotLength of the first checked host.
+		SlotLength time.Duration `json:"slot_length"`
+
+		// Hosts are results of checked hosts.
+		Hosts []HostHealth `json:"hosts"`
+	}
+
+	// HostHealth is health of single host.
+	HostHealth struct {
+		// URL is base url of the host.
+		URL string `json:"url"`
+
+		// Healthy is true when API version is served by the host
+		// and its tip is not older than MaxTipLag.
+		Healthy bool `json:"healthy"`
+
+		// Reachable is true when host responded.
+		Reachable bool `json:"reachable"`
+
+		// StatusCode of /tip response.
+		StatusCode int `json:"status_code,omitempty"`
+
+		// Latency of /tip request.
+		Latency time.Duration `json:"latency"`
+
+		// Tip of the host.
+		Tip *Tip `json:"tip,omitempty"`
+
+		// BlockTime is time of the tip block.
+		BlockTime time.Time `json:"block_time,omitempty"`
+
+		// Lag is age of the tip block.
+		Lag time.Duration `json:"lag"`
+
+		// LagSlots is Lag in slots.
+		LagSlots int64 `json:"lag_slots"`
+
+		// SlotLength of the network served by the host
+		// used to convert lag to slots.
+		SlotLength time.Duration `json:"slot_length"`
+
+		// BlocksBehind is number of blocks the host is behind
+		// the best tip of checked hosts.
+		BlocksBehind int `json:"blocks_behind"`
+
+		// Error describing why host is unhealthy.
+		Error string `json:"error,omitempty"`
+	}
+)
+
+// MaxTipLag sets maximum age of the chain tip used by Health
+// to consider instance healthy, DefaultMaxTipLag by default.
+func MaxTipLag(d time.Duration) Option {
+	return func(c *Client) error {
+		if d <= 0 {
+			return ErrMaxTipLag
+		}
+		c.mux.Lock()
+		defer c.mux.Unlock()
+		c.maxTipLag = d
+		return nil
+	}
+}
+
+// Health checks whether configured host serves the API version and
+// its chain tip is not lagging behind wall clock more than MaxTipLag.
+// When instances are configured all of them are checked and compared,
+// hosts (base urls e.g. https://api.koios.rest/api/v0) can be provided
+// to check them instead. Report is returned with error matching
+// ErrUnhealthy when none of checked hosts is healthy.
+func (c *Client) Health(ctx context.Context, hosts ...string) (*HealthReport, error) {
+	c.mux.RLock()
+	maxLag := c.maxTipLag
+	pool := c.instances
+	c.mux.RUnlock()
+	if maxLag == 0 {
+		maxLag = DefaultMaxTipLag
+	}
+
+	// network of the client applies only to configured hosts.
+	configured := len(hosts) == 0
+	if configured {
+		if pool != nil {
+			for _, s := range pool.status() {
+				hosts = append(hosts, s.URL)
+			}
+		} else {
+			hosts = append(hosts, c.BaseURL())
+		}
+	}
+
+	report := &HealthReport{
+		CheckedAt: time.Now().UTC(),
+		MaxTipLag: maxLag,
+		Hosts:     make([]HostHealth, len(hosts)),
+	}
+
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		go func(h *HostHealth, host string) {
+			defer wg.Done()
+			*h = c.hostHealth(ctx, host, report.CheckedAt, maxLag, c.slotLength(ctx, host, configured))
+		}(&report.Hosts[i], host)
+	}
+	wg.Wait()
+	report.SlotLength = report.Hosts[0].SlotLength
+
+	best := 0
+	for _, h := range report.Hosts {
+		if h.Tip != nil && h.Tip.BlockNo > best {
+			best = h.Tip.BlockNo
+		}
+	}
+	var reasons []string
+	for i := range report.Hosts {
+		h := &report.Hosts[i]
+		if h.Tip != nil {
+			h.BlocksBehind = best - h.Tip.BlockNo
+		}
+		if h.Healthy {
+			report.Healthy = true
+		} else {
+			reasons = append(reasons, h.URL+": "+h.Error)
+		}
+	}
+	if !report.Healthy {
+		return report, fmt.Errorf("%w: %s", ErrUnhealthy, strings.Join(reasons, "; "))
+	}
+	return report, nil
+}
+
+// hostHealth checks single host.
+func (c *Client) hostHealth(
+	ctx context.Context,
+	host string,
+	now time.Time,
+	maxLag time.Duration,
+	slotLength time.Duration,
+) HostHealth {
+	h := HostHealth{URL: host, SlotLength: slotLength}
+	start := time.Now()
+	res, err := c.GetTip(ctx, WithHost(host))
+	h.Latency = time.Since(start)
+	h.StatusCode = res.StatusCode
+	h.Reachable = res.StatusCode != 0
+	if err != nil {
+		if res.StatusCode == http.StatusNotFound {
+			h.Error = "api version is not served"
+		} else {
+			h.Error = err.Error()
+		}
+		return h
+	}
+	if res.Data == nil {
+		h.Error = ErrNoTip.Error()
+		return h
+	}
+	h.Tip = res.Data
+
+	blockTime, err := parseBlockTime(res.Data.BlockTime)
+	if err != nil {
+		h.Error = err.Error()
+		return h
+	}
+	h.BlockTime = blockTime
+	h.Lag = now.Sub(blockTime)
+	if h.Lag < 0 {
+		h.Lag = 0
+	}
+	h.LagSlots = int64(h.Lag / slotLength)
+	if h.Lag > maxLag {
+		h.Error = fmt.Sprintf("tip is %s (%d slots) old", h.Lag.Round(time.Second), h.LagSlots)
+		return h
+	}
+	h.Healthy = true
+	return h
+}
+
+// slotLength returns slot length of the network served by host. Network
+// of the client is used for configured hosts, genesis is queried from
+// host when host is not configured or network of the client is not known.
+func (c *Client) slotLength(ctx context.Context, host string, configured bool) time.Duration {
+	if n, ok := c.Network(); configured && ok && n.Genesis.SlotLength > 0 {
+		return n.Genesis.SlotLength
+	}
+	res, err := c.GetGenesis(ctx, WithHost(host))
+	if err == nil && res.Data != nil {
+		if secs, err := strconv.ParseFloat(res.Data.Slotlength, 64); err == nil && secs > 0 {
+			return time.Duration(secs * float64(time.Second))
+		}
+	}
+	return time.Second
+}
+
+// parseBlockTime parses block time which is either unix
+// timestamp or ISO 8601 time without timezone (UTC).
+func parseBlockTime(s string) (time.Time, error) {
+	if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(secs, 0).UTC(), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid block time %q", s)
+}
@@ -0,0 +1,157 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/howijd/koios-rest-go-client"
+)
+
+func newHealthServer(t *testing.T, blockNo int, age time.Duration) *httptest.Server {
+	return newNetworkHealthServer(t, blockNo, age, "2")
+}
+
+// newNetworkHealthServer returns health server of network with slotLength in seconds.
+func newNetworkHealthServer(t *testing.T, blockNo int, age time.Duration, slotLength string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v0/tip":
+			blockTime := time.Now().Add(-age).UTC().Format("2006-01-02T15:04:05")
+			fmt.Fprintf(w, `[{"block_no":%d,"block_time":"%s"}]`, blockNo, blockTime)
+		case "/api/v0/genesis":
+			fmt.Fprintf(w, `[{"slotlength":"%s"}]`, slotLength)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"not found"}`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestHealth(t *testing.T) {
+	fresh := newHealthServer(t, 100, 20*time.Second)
+	api := newTestClient(t, fresh.Config.Handler)
+
+	report, err := api.Health(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, report.Healthy)
+	assert.Equal(t, koios.DefaultMaxTipLag, report.MaxTipLag)
+	assert.Equal(t, 2*time.Second, report.SlotLength)
+	if assert.Len(t, report.Hosts, 1) {
+		h := report.Hosts[0]
+		assert.True(t, h.Healthy)
+		assert.True(t, h.Reachable)
+		assert.Equal(t, api.BaseURL(), h.URL)
+		assert.Equal(t, 100, h.Tip.BlockNo)
+		assert.InDelta(t, 20*time.Second, h.Lag, float64(2*time.Second))
+		assert.InDelta(t, 10, h.LagSlots, 1)
+		assert.Empty(t, h.Error)
+	}
+
+	assert.NoError(t, koios.MaxTipLag(10*time.Second)(api))
+	report, err = api.Health(context.Background())
+	assert.ErrorIs(t, err, koios.ErrUnhealthy)
+	assert.False(t, report.Healthy)
+	assert.Contains(t, report.Hosts[0].Error, "old")
+
+	_, err = koios.New(koios.MaxTipLag(0))
+	assert.ErrorIs(t, err, koios.ErrMaxTipLag)
+}
+
+func TestHealthHosts(t *testing.T) {
+	fresh := newHealthServer(t, 100, 10*time.Second)
+	behind := newHealthServer(t, 97, 70*time.Second)
+	stale := newHealthServer(t, 50, time.Hour)
+	down := newHealthServer(t, 0, 0)
+	down.Close()
+
+	api := newTestClient(t, fresh.Config.Handler)
+	report, err := api.Health(context.Background(),
+		fresh.URL+"/api/v0",
+		behind.URL+"/api/v0",
+		stale.URL+"/api/v0",
+		stale.URL+"/api/v1",
+		down.URL+"/api/v0",
+	)
+	assert.NoError(t, err)
+	assert.True(t, report.Healthy)
+	if assert.Len(t, report.Hosts, 5) {
+		assert.True(t, report.Hosts[0].Healthy)
+		assert.Equal(t, 0, report.Hosts[0].BlocksBehind)
+
+		assert.True(t, report.Hosts[1].Healthy)
+		assert.Equal(t, 3, report.Hosts[1].BlocksBehind)
+
+		assert.False(t, report.Hosts[2].Healthy)
+		assert.Equal(t, 50, report.Hosts[2].BlocksBehind)
+
+		assert.False(t, report.Hosts[3].Healthy)
+		assert.True(t, report.Hosts[3].Reachable)
+		assert.Equal(t, http.StatusNotFound, report.Hosts[3].StatusCode)
+		assert.Equal(t, "api version is not served", report.Hosts[3].Error)
+
+		assert.False(t, report.Hosts[4].Healthy)
+		assert.False(t, report.Hosts[4].Reachable)
+		assert.NotEmpty(t, report.Hosts[4].Error)
+	}
+
+	_, err = api.Health(context.Background(), stale.URL+"/api/v0", down.URL+"/api/v0")
+	assert.ErrorIs(t, err, koios.ErrUnhealthy)
+}
+
+func TestHealthNetworks(t *testing.T) {
+	configured := newNetworkHealthServer(t, 100, 20*time.Second, "5")
+	slow := newNetworkHealthServer(t, 100, 20*time.Second, "2")
+	fast := newNetworkHealthServer(t, 100, 20*time.Second, "1")
+	api := newTestClient(t, configured.Config.Handler,
+		koios.ResponseCache(koios.NewLRUCache(10), koios.DefaultCachePolicy()))
+
+	// genesis of each host is cached separately.
+	for i := 0; i < 2; i++ {
+		report, err := api.Health(context.Background(), slow.URL+"/api/v0", fast.URL+"/api/v0")
+		assert.NoError(t, err)
+		assert.Equal(t, 2*time.Second, report.SlotLength)
+		if assert.Len(t, report.Hosts, 2) {
+			assert.Equal(t, 2*time.Second, report.Hosts[0].SlotLength)
+			assert.InDelta(t, 10, report.Hosts[0].LagSlots, 1)
+			assert.Equal(t, time.Second, report.Hosts[1].SlotLength)
+			assert.InDelta(t, 20, report.Hosts[1].LagSlots, 1)
+		}
+	}
+
+	genesis, err := api.GetGenesis(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, genesis.Cached)
+	assert.Equal(t, "5", genesis.Data.Slotlength)
+
+	// network of the client applies only to configured hosts.
+	assert.NoError(t, koios.UseNetwork(koios.Network{Name: "local", Host: "localhost",
+		Genesis: koios.NetworkGenesis{SlotLength: 3 * time.Second}})(api))
+	report, err := api.Health(context.Background(), fast.URL+"/api/v0")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, report.SlotLength)
+}
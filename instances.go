@@ -0,0 +1,303 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RoundRobin   : selects instances in weighted round-robin order.
+// LowestLatency: selects instance with lowest observed latency.
+const (
+	RoundRobin Balancer = iota
+	LowestLatency
+)
+
+// InstanceCooldown is duration for which failed instance
+// is not selected unless all other instances are failing too.
+const InstanceCooldown = 30 * time.Second
+
+type (
+	// Balancer defines how instances of the instance pool are selected.
+	Balancer uint8
+
+	// Instance is Koios instance used by the API client.
+	Instance struct {
+		// URL is base url of the instance
+		// e.g. https://api.koios.rest/api/v0.
+		URL string
+
+		// Weight of the instance used by RoundRobin balancer,
+		// weight 0 is treated as 1.
+		Weight uint
+	}
+
+	// InstanceStatus is observed status of the instance.
+	InstanceStatus struct {
+		// URL is base url of the instance.
+		URL string `json:"url"`
+
+		// Healthy is false when last request to instance failed
+		// and instance is in cooldown.
+		Healthy bool `json:"healthy"`
+
+		// Latency is moving average of observed request latency.
+		Latency time.Duration `json:"latency"`
+
+		// Failures is count of consecutive failed requests.
+		Failures int `json:"failures"`
+
+		// Preferred is true when instance was selected as preferred
+		// e.g. by PreferFreshestInstance.
+		Preferred bool `json:"preferred"`
+	}
+
+	instancePool struct {
+		mux       sync.Mutex
+		balancer  Balancer
+		instances []*instance
+		preferred *instance
+	}
+
+	instance struct {
+		url       *url.URL
+		weight    int
+		current   int
+		latency   time.Duration
+		failures  int
+		downUntil time.Time
+	}
+)
+
+// Instances configures pool of Koios instances used by the API client
+// instead of single host configured with Host, Port, Schema and APIVersion.
+//
+// Instance for each request is selected by balancer. Instances are
+// passively health checked, when request to instance fails with
+// connection error or 5xx/429 response, request is transparently sent
+// to next instance (except /submittx) and failed instance is not used
+// for InstanceCooldown unless all instances are failing.
+func Instances(balancer Balancer, instances ...Instance) Option {
+	return func(c *Client) error {
+		if len(instances) == 0 {
+			return ErrNoInstances
+		}
+		pool := &instancePool{balancer: balancer}
+		for _, inst := range instances {
+			u, err := url.ParseRequestURI(inst.URL)
+			if err != nil {
+				return err
+			}
+			if !strings.HasSuffix(u.Path, "/") {
+				u.Path += "/"
+			}
+			w := int(inst.Weight)
+			if w == 0 {
+				w = 1
+			}
+			pool.instances = append(pool.instances, &instance{url: u, weight: w})
+		}
+		c.mux.Lock()
+		defer c.mux.Unlock()
+		c.instances = pool
+		c.url = pool.instances[0].url
+		c.network = networkByInstances(pool.instances)
+		c.checkNetwork = false
+		return nil
+	}
+}
+
+// InstancesStatus returns observed status of configured instances.
+func (c *Client) InstancesStatus() []InstanceStatus {
+	c.mux.RLock()
+	pool := c.instances
+	c.mux.RUnlock()
+	if pool == nil {
+		return nil
+	}
+	return pool.status()
+}
+
+// PreferFreshestInstance queries /tip of all configured instances and
+// makes instance with the most recent tip preferred for following requests
+// while it stays healthy. It returns base url of selected instance.
+// Call it periodically to keep using instance which is best in sync.
+func (c *Client) PreferFreshestInstance(ctx context.Context) (string, error) {
+	c.mux.RLock()
+	pool := c.instances
+	limiter := c.limiter
+	c.mux.RUnlock()
+	if pool == nil {
+		return "", ErrNoInstances
+	}
+
+	var (
+		best    *instance
+		bestTip int
+		lastErr error
+	)
+	for _, inst := range pool.instances {
+		res := &TipResponse{}
+		call := &Call{Endpoint: "tip", Attempt: 1, Response: &res.Response}
+		rsp, err := c.attempt(ctx, call, limiter, http.MethodGet,
+			inst.url.ResolveReference(&url.URL{Path: "tip"}).String(), nil, nil)
+		if err == nil {
+			tips := []Tip{}
+			err = readAndUnmarshalResponse(rsp, &res.Response, &tips)
+			if err == nil && len(tips) == 1 && (best == nil || tips[0].BlockNo > bestTip) {
+				best, bestTip = inst, tips[0].BlockNo
+			}
+		}
+		if ctx.Err() == nil && call.sent {
+			pool.report(inst, call.latency, err != nil)
+		}
+		if err != nil {
+			lastErr = err
+		}
+	}
+	if best == nil {
+		return "", lastErr
+	}
+	pool.mux.Lock()
+	pool.preferred = best
+	pool.mux.Unlock()
+	return best.url.String(), nil
+}
+
+// pick selects instance for next request skipping already tried instances.
+func (p *instancePool) pick(tried map[*instance]bool) *instance {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	now := time.Now()
+	var healthy, down []*instance
+	for _, inst := range p.instances {
+		if tried[inst] {
+			continue
+		}
+		if now.Before(inst.downUntil) {
+			down = append(down, inst)
+		} else {
+			healthy = append(healthy, inst)
+		}
+	}
+
+	if len(healthy) == 0 {
+		// all instances are failing, pick the one recovering first.
+		var next *instance
+		for _, inst := range down {
+			if next == nil || inst.downUntil.Before(next.downUntil) {
+				next = inst
+			}
+		}
+		return next
+	}
+
+	if p.preferred != nil {
+		for _, inst := range healthy {
+			if inst == p.preferred {
+				return inst
+			}
+		}
+	}
+
+	switch p.balancer {
+	case LowestLatency:
+		var next *instance
+		for _, inst := range healthy {
+			// instances without observed latency are tried first.
+			if inst.latency == 0 {
+				return inst
+			}
+			if next == nil || inst.latency < next.latency {
+				next = inst
+			}
+		}
+		return next
+	default:
+		// smooth weighted round-robin.
+		var (
+			next  *instance
+			total int
+		)
+		for _, inst := range healthy {
+			inst.current += inst.weight
+			total += inst.weight
+			if next == nil || inst.current > next.current {
+				next = inst
+			}
+		}
+		next.current -= total
+		return next
+	}
+}
+
+// report records result of request sent to instance.
+func (p *instancePool) report(inst *instance, latency time.Duration, failed bool) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	if failed {
+		inst.failures++
+		inst.downUntil = time.Now().Add(InstanceCooldown)
+		return
+	}
+	inst.failures = 0
+	inst.downUntil = time.Time{}
+	if inst.latency == 0 {
+		inst.latency = latency
+	} else {
+		// exponentially weighted moving average.
+		inst.latency = (inst.latency*4 + latency) / 5
+	}
+}
+
+func (p *instancePool) status() []InstanceStatus {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	now := time.Now()
+	var status []InstanceStatus
+	for _, inst := range p.instances {
+		status = append(status, InstanceStatus{
+			URL:       inst.url.String(),
+			Healthy:   !now.Before(inst.downUntil),
+			Latency:   inst.latency,
+			Failures:  inst.failures,
+			Preferred: inst == p.preferred,
+		})
+	}
+	return status
+}
+
+func (p *instancePool) len() int {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return len(p.instances)
+}
+
+// isInstanceFailure reports whether response indicates
+// that instance is not able to serve requests.
+func isInstanceFailure(rsp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return rsp.StatusCode >= 500 || rsp.StatusCode == http.StatusTooManyRequests
+}
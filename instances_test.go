@@ -0,0 +1,111 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/howijd/koios-rest-go-client"
+)
+
+func newTipServer(t *testing.T, blockNo int, status int) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/v0/tip" {
+			fmt.Fprintf(w, `[{"block_no":%d}]`, blockNo)
+			return
+		}
+		w.WriteHeader(status)
+		fmt.Fprint(w, `[]`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestInstancesFailover(t *testing.T) {
+	failing := newTipServer(t, 10, http.StatusServiceUnavailable)
+	healthy := newTipServer(t, 12, http.StatusOK)
+
+	api, err := koios.New(
+		koios.RateLimit(255),
+		koios.Instances(koios.RoundRobin,
+			koios.Instance{URL: failing.URL + "/api/v0"},
+			koios.Instance{URL: healthy.URL + "/api/v0"},
+		),
+	)
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err := api.GetPoolList(context.Background())
+		assert.NoError(t, err)
+	}
+
+	status := api.InstancesStatus()
+	if assert.Len(t, status, 2) {
+		assert.False(t, status[0].Healthy)
+		assert.True(t, status[1].Healthy)
+	}
+
+	preferred, err := api.PreferFreshestInstance(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, healthy.URL+"/api/v0/", preferred)
+}
+
+func TestInstancesLatency(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[]`)
+	}))
+	t.Cleanup(slow.Close)
+	fast := newTipServer(t, 12, http.StatusOK)
+
+	// second request waits for rate limit which is not instance latency.
+	api, err := koios.New(
+		koios.RateLimiter(koios.NewTokenBucket(4, 1)),
+		koios.Instances(koios.LowestLatency,
+			koios.Instance{URL: slow.URL + "/api/v0"},
+			koios.Instance{URL: fast.URL + "/api/v0"},
+		),
+	)
+	assert.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, err := api.GetPoolList(context.Background())
+		assert.NoError(t, err)
+	}
+
+	status := api.InstancesStatus()
+	if assert.Len(t, status, 2) {
+		assert.GreaterOrEqual(t, status[0].Latency, 50*time.Millisecond)
+		assert.Less(t, status[1].Latency, 50*time.Millisecond)
+	}
+}
+
+func TestInstancesOption(t *testing.T) {
+	_, err := koios.New(koios.Instances(koios.RoundRobin))
+	assert.ErrorIs(t, err, koios.ErrNoInstances)
+
+	_, err = koios.New(koios.Instances(koios.LowestLatency, koios.Instance{URL: "::"}))
+	assert.Error(t, err)
+}
@@ -24,10 +24,12 @@
 package koios
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
 	"runtime"
@@ -40,25 +42,35 @@ import (
 	"golang.org/x/text/language"
 )
 
-// MainnetHost       : is primay and default api host.
-// GuildHost         : is Guild network host.
-// TestnetHost       : is api host for testnet.
-// DefaultAPIVersion : is openapi spec version e.g. /v0.
-// DefaultPort       : default port used by api client.
-// DefaultSchema     : default schema used by api client.
-// LibraryVersion    : koios go library version.
-// DefaultRateLimit  : is default rate limit used by api client.
-// DefaultOrigin     : is default origin header used by api client.
+// MainnetHost             : is primay and default api host.
+// GuildHost               : is Guild network host.
+// TestnetHost             : is api host for testnet.
+// PreprodHost             : is api host for preprod network.
+// PreviewHost             : is api host for preview network.
+// DefaultAPIVersion       : is openapi spec version e.g. /v0.
+// DefaultPort             : default port used by api client.
+// DefaultSchema           : default schema used by api client.
+// LibraryVersion          : koios go library version.
+// DefaultRateLimit        : is default rate limit used by api client.
+// DefaultOrigin           : is default origin header used by api client.
+// DefaultPageSize         : is default page size used by paginated iterators and streams.
+// DefaultChunkSize        : is default max number of items in single bulk request.
+// DefaultChunkConcurrency : is default number of bulk request chunks in flight.
 const (
-	MainnetHost              = "api.koios.rest"
-	GuildHost                = "guild.koios.rest"
-	TestnetHost              = "testnet.koios.rest"
-	DefaultAPIVersion        = "v0"
-	DefaultPort       uint16 = 443
-	DefaultSchema            = "https"
-	LibraryVersion           = "v0"
-	DefaultRateLimit  uint8  = 5
-	DefaultOrigin            = "https://github.com/howijd/koios-rest-go-client"
+	MainnetHost                    = "api.koios.rest"
+	GuildHost                      = "guild.koios.rest"
+	TestnetHost                    = "testnet.koios.rest"
+	PreprodHost                    = "preprod.koios.rest"
+	PreviewHost                    = "preview.koios.rest"
+	DefaultAPIVersion              = "v0"
+	DefaultPort             uint16 = 443
+	DefaultSchema                  = "https"
+	LibraryVersion                 = "v0"
+	DefaultRateLimit        uint8  = 5
+	DefaultOrigin                  = "https://github.com/howijd/koios-rest-go-client"
+	DefaultPageSize         uint   = 1000
+	DefaultChunkSize        uint   = 100
+	DefaultChunkConcurrency uint   = 4
 )
 
 // Predefined errors used by the library.
@@ -68,28 +80,83 @@ var (
 	ErrHTTPClientChange         = errors.New("http.Client can only be set as option to koios.New")
 	ErrOriginSet                = errors.New("origin can only be set as option to koios.New")
 	ErrRateLimitRange           = errors.New("rate limit must be between 1-255 requests per sec")
+	ErrLimiterNil               = errors.New("rate limiter can not be nil")
+	ErrRetryAttempts            = errors.New("retry policy max attempts must be at least 1")
+	ErrRetryJitter              = errors.New("retry policy jitter must be between 0-1")
+	ErrCacheNil                 = errors.New("cache can not be nil")
+	ErrNoTip                    = errors.New("missing chain tip")
+	ErrNoInstances              = errors.New("no koios instances configured")
+	ErrPageSize                 = errors.New("page size must be greater than 0")
+	ErrChunkSize                = errors.New("bulk chunk size must be greater than 0")
+	ErrChunkConcurrency         = errors.New("bulk chunk concurrency must be greater than 0")
+	ErrMiddlewareNil            = errors.New("middleware can not be nil")
+	ErrTelemetryNil             = errors.New("telemetry can not be nil")
+	ErrNetworkHost              = errors.New("network host must be set")
+	ErrNetworkMismatch          = errors.New("address does not belong to the network")
+	ErrUnsupportedEncoding      = errors.New("unsupported content encoding")
+	ErrMaxConcurrentRequests    = errors.New("max concurrent requests must be greater than 0")
+	ErrAuthToken                = errors.New("invalid auth token")
+	ErrAuthProviderNil          = errors.New("auth token provider can not be nil")
+	ErrConfig                   = errors.New("invalid configuration")
+	ErrLoggerNil                = errors.New("logger can not be nil")
+	ErrBreakerThreshold         = errors.New("circuit breaker failure threshold must be at least 1")
+	ErrBreakerCoolDown          = errors.New("circuit breaker cool down must be greater than 0")
+	ErrMaxTipLag                = errors.New("max tip lag must be greater than 0")
 	ErrResponseIsNotJSON        = errors.New("go non json response")
 	ErrNoTxHash                 = errors.New("missing transaxtion hash(es)")
 	ErrNoAddress                = errors.New("missing address")
+	ErrInvalidAddress           = errors.New("invalid address")
+	ErrNoStakeCredential        = errors.New("address has no stake credential")
+	ErrNoPaymentCredential      = errors.New("address has no payment credential")
 	ErrNoPoolID                 = errors.New("missing pool id")
 )
 
+// Error classes of failed API requests. Errors returned by the library
+// can be matched against these with errors.Is e.g.
+// errors.Is(err, koios.ErrNotFound).
+var (
+	ErrNotFound          = errors.New("not found")
+	ErrRateLimited       = errors.New("rate limited")
+	ErrServerUnavailable = errors.New("server unavailable")
+	ErrBadRequest        = errors.New("bad request")
+	ErrTimeout           = errors.New("timeout")
+	ErrCircuitOpen       = errors.New("circuit open")
+	ErrUnhealthy         = errors.New("unhealthy")
+)
+
 type (
 	// Client is api client instance.
 	Client struct {
-		mux             sync.RWMutex
-		host            string
-		version         string
-		port            uint16
-		schema          string
-		origin          string
-		url             *url.URL
-		client          *http.Client
-		commonHeaders   http.Header
-		reqInterval     time.Duration
-		lastRequest     time.Time
-		totalReq        uint64
-		reqStatsEnabled bool
+		mux              sync.RWMutex
+		host             string
+		version          string
+		port             uint16
+		schema           string
+		origin           string
+		url              *url.URL
+		client           *http.Client
+		commonHeaders    http.Header
+		limiter          Limiter
+		customLimiter    bool
+		auth             *authState
+		tierLimits       map[int]uint8
+		slots            *concurrencyLimiter
+		retry            *RetryPolicy
+		cache            *responseCache
+		instances        *instancePool
+		breakers         *circuitBreakers
+		pageSize         uint
+		chunkSize        int
+		chunkConcurrency int
+		middlewares      []Middleware
+		telemetry        Telemetry
+		logger           Logger
+		network          *Network
+		checkNetwork     bool
+		exactCount       bool
+		maxTipLag        time.Duration
+		totalReq         uint64
+		reqStatsEnabled  bool
 	}
 
 	// Option is callback function which can be implemented
@@ -188,11 +255,20 @@ type (
 		// ContentRange response header if present.
 		ContentRange string `json:"content_range,omitempty"`
 
+		// PageInfo is parsed ContentRange if present.
+		PageInfo *PageInfo `json:"page_info,omitempty"`
+
+		// Cached is true when response was served from cache.
+		Cached bool `json:"cached,omitempty"`
+
 		// Error response body if present.
 		Error *ResponseError `json:"error,omitempty"`
 
 		// Stats of the request if stats are enabled.
 		Stats *RequestStats `json:"stats,omitempty"`
+
+		// logger logs failures to decode the response.
+		logger Logger
 	}
 
 	// RequestStats represent collected request stats if collecting
@@ -219,10 +295,32 @@ type (
 
 		// ReqDurStr String representation of ReqDur.
 		ReqDurStr string `json:"req_dur_str,omitempty"`
+
+		// QueueWaitDur time request waited for free slot
+		// when MaxConcurrentRequests is configured.
+		QueueWaitDur time.Duration `json:"queue_wait_dur,omitempty"`
+
+		// ContentEncoding of the response body if it was compressed.
+		ContentEncoding string `json:"content_encoding,omitempty"`
+
+		// BytesCompressed number of response body bytes received
+		// over the wire.
+		BytesCompressed int64 `json:"bytes_compressed,omitempty"`
+
+		// BytesDecompressed number of response body bytes after
+		// decompression, equals BytesCompressed when response
+		// was not compressed.
+		BytesDecompressed int64 `json:"bytes_decompressed,omitempty"`
 	}
 
-	// ResponseError represents api error messages.
+	// ResponseError represents api error messages. It implements error
+	// interface and is returned by all API methods when request fails,
+	// use errors.Is with error classes e.g. ErrNotFound to inspect it
+	// or errors.As to access details reported by server.
 	ResponseError struct {
+		// StatusCode of the HTTP response if server responded with error.
+		StatusCode int `json:"status_code,omitempty"`
+
 		// Hint of the error reported by server.
 		Hint string `json:"hint,omitempty"`
 
@@ -234,6 +332,9 @@ type (
 
 		// Message is error message reported by server.
 		Message string `json:"message,omitempty"`
+
+		// err is underlying error e.g. transport or decoding error.
+		err error
 	}
 )
 
@@ -252,6 +353,7 @@ type (
 func New(opts ...Option) (*Client, error) {
 	c := &Client{
 		host:          MainnetHost,
+		network:       networkByHost(MainnetHost),
 		version:       DefaultAPIVersion,
 		port:          DefaultPort,
 		schema:        DefaultSchema,
@@ -260,11 +362,14 @@ func New(opts ...Option) (*Client, error) {
 	// set default base url
 	_ = c.updateBaseURL()
 	// set default rate limit for outgoing requests.
-	_ = RateLimit(DefaultRateLimit)(c)
+	c.limiter = NewTokenBucket(float64(DefaultRateLimit), 1)
+	c.pageSize = DefaultPageSize
+	// set default chunking of bulk requests.
+	_ = BulkChunking(DefaultChunkSize, DefaultChunkConcurrency)(c)
 
 	// set default common headers
 	c.commonHeaders.Set("Accept", "application/json")
-	c.commonHeaders.Set("Accept-Encoding", "gzip, deflate")
+	c.commonHeaders.Set("Accept-Encoding", strings.Join(DefaultAcceptEncoding, ", "))
 	c.commonHeaders.Set(
 		"User-Agent",
 		fmt.Sprintf(
@@ -300,10 +405,15 @@ func New(opts ...Option) (*Client, error) {
 
 // Host returns option apply func which can be used to change the
 // baseurl hostname https://<host>/api/v0/
+// Network profile is selected automatically for hosts of predefined
+// networks, use UseNetwork to configure custom network or to validate
+// addresses against the network.
 func Host(host string) Option {
 	return func(c *Client) error {
 		c.mux.Lock()
 		c.host = host
+		c.network = networkByHost(host)
+		c.checkNetwork = false
 		c.mux.Unlock()
 		return c.updateBaseURL()
 	}
@@ -370,14 +480,29 @@ func HTTPClient(client *http.Client) Option {
 // RateLimit sets requests per second this client is allowed to create
 // and effectievely rate limits outgoing requests.
 // Let's respect usage of the community provided resources.
+//
+// RateLimit is shortcut for RateLimiter(NewTokenBucket(reqps, 1)).
 func RateLimit(reqps uint8) Option {
 	return func(c *Client) error {
 		if reqps == 0 {
 			return ErrRateLimitRange
 		}
+		return RateLimiter(NewTokenBucket(float64(reqps), 1))(c)
+	}
+}
+
+// RateLimiter sets Limiter used to rate limit outgoing requests.
+// Use it e.g. to allow bursts with NewTokenBucket or to share
+// single Limiter between multiple API clients.
+func RateLimiter(limiter Limiter) Option {
+	return func(c *Client) error {
+		if limiter == nil {
+			return ErrLimiterNil
+		}
 		c.mux.Lock()
 		defer c.mux.Unlock()
-		c.reqInterval = time.Second / time.Duration(reqps)
+		c.limiter = limiter
+		c.customLimiter = true
 		return nil
 	}
 }
@@ -416,6 +541,85 @@ func CollectRequestsStats(enabled bool) Option {
 	}
 }
 
+// Error implements error interface.
+func (e *ResponseError) Error() string {
+	var parts []string
+	if e.StatusCode != 0 {
+		parts = append(parts, fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)))
+	}
+	if len(e.Code) > 0 {
+		parts = append(parts, e.Code)
+	}
+	if len(e.Message) > 0 {
+		parts = append(parts, e.Message)
+	}
+	if len(e.Details) > 0 {
+		parts = append(parts, e.Details)
+	}
+	if len(e.Hint) > 0 {
+		parts = append(parts, "hint: "+e.Hint)
+	}
+	if len(parts) == 0 {
+		return "koios: unknown error"
+	}
+	return "koios: " + strings.Join(parts, ": ")
+}
+
+// Unwrap returns underlying error if any.
+func (e *ResponseError) Unwrap() error {
+	return e.err
+}
+
+// Is reports whether error belongs to error class target
+// e.g. ErrNotFound, ErrRateLimited.
+func (e *ResponseError) Is(target error) bool {
+	code := e.StatusCode
+	switch target {
+	case ErrNotFound:
+		return code == http.StatusNotFound
+	case ErrRateLimited:
+		return code == http.StatusTooManyRequests
+	case ErrServerUnavailable:
+		return code >= 500
+	case ErrBadRequest:
+		return code >= 400 && code < 500 &&
+			code != http.StatusNotFound &&
+			code != http.StatusRequestTimeout &&
+			code != http.StatusTooManyRequests
+	case ErrTimeout:
+		if code == http.StatusRequestTimeout || code == http.StatusGatewayTimeout {
+			return true
+		}
+		if errors.Is(e.err, context.DeadlineExceeded) {
+			return true
+		}
+		var nerr net.Error
+		return errors.As(e.err, &nerr) && nerr.Timeout()
+	}
+	return false
+}
+
+// readAndUnmarshalResponse reads the response body and decodes it into dest.
+// When server responded with error status or decoding fails error is applied
+// to res and returned.
+func readAndUnmarshalResponse(rsp *http.Response, res *Response, dest interface{}) error {
+	body, err := readResponseBody(rsp)
+	if err != nil {
+		res.logResponseError(rsp.Request.Context(), body, err)
+		return res.applyError(body, err)
+	}
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		res.logResponseError(rsp.Request.Context(), body, nil)
+		return res.applyError(body, nil)
+	}
+	if err = json.Unmarshal(body, dest); err != nil {
+		res.logResponseError(rsp.Request.Context(), body, err)
+		return res.applyError(body, err)
+	}
+	res.ready()
+	return nil
+}
+
 func readResponseBody(rsp *http.Response) ([]byte, error) {
 	body, err := ioutil.ReadAll(rsp.Body)
 	defer func() { _ = rsp.Body.Close() }()
@@ -428,13 +632,31 @@ func readResponseBody(rsp *http.Response) ([]byte, error) {
 	return body, nil
 }
 
-func (r *Response) applyError(body []byte, err error) {
-	r.Error = &ResponseError{}
-	_ = json.Unmarshal(body, r.Error)
-	if err != nil && len(r.Error.Message) == 0 {
-		r.Error.Message = err.Error()
+// applyError sets r.Error from error response body and/or err
+// and returns it.
+func (r *Response) applyError(body []byte, err error) error {
+	var rerr *ResponseError
+	if errors.As(err, &rerr) {
+		r.Error = rerr
+		r.ready()
+		return rerr
+	}
+	r.Error = &ResponseError{err: err}
+	if r.StatusCode < 200 || r.StatusCode > 299 {
+		r.Error.StatusCode = r.StatusCode
+	}
+	if len(body) > 0 {
+		_ = json.Unmarshal(body, r.Error)
+	}
+	if len(r.Error.Message) == 0 {
+		if err != nil {
+			r.Error.Message = err.Error()
+		} else if r.Error.StatusCode != 0 {
+			r.Error.Message = http.StatusText(r.Error.StatusCode)
+		}
 	}
 	r.ready()
+	return r.Error
 }
 
 func (r *Response) ready() {
@@ -451,5 +673,8 @@ func (r *Response) applyRsp(rsp *http.Response) {
 	r.Status = rsp.Status
 	r.Date = rsp.Header.Get("date")
 	r.ContentRange = rsp.Header.Get("content-range")
+	if pi, ok := parseContentRange(r.ContentRange); ok {
+		r.PageInfo = &pi
+	}
 	r.ContentLocation = rsp.Header.Get("content-location")
 }
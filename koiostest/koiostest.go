@@ -0,0 +1,506 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package koiostest provides in-process fake Koios server for testing
+// Koios API integrations without network access.
+//
+// Server serves all endpoints supported by the API client from JSON
+// fixtures. Default fixtures are included, custom fixtures can be loaded
+// from directory containing <endpoint>.json files e.g. tip.json, tx_info.json.
+//
+// e.g.
+//
+//	srv := koiostest.NewServer()
+//	defer srv.Close()
+//
+//	api, err := srv.Client()
+//	if err != nil {
+//		...
+//	}
+//	res, err := api.GetTip(ctx)
+package koiostest
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/howijd/koios-rest-go-client"
+)
+
+// MaxRows is maximum number of rows returned in single response
+// same as on Koios instances.
+const MaxRows = 1000
+
+//go:embed fixtures/*.json
+var defaultFixtures embed.FS
+
+// Endpoints lists endpoints served by Server and their HTTP methods.
+var Endpoints = map[string]string{
+	"tip":                http.MethodGet,
+	"genesis":            http.MethodGet,
+	"totals":             http.MethodGet,
+	"epoch_info":         http.MethodGet,
+	"epoch_params":       http.MethodGet,
+	"blocks":             http.MethodGet,
+	"block_info":         http.MethodGet,
+	"block_txs":          http.MethodGet,
+	"tx_info":            http.MethodPost,
+	"tx_utxos":           http.MethodPost,
+	"tx_metadata":        http.MethodPost,
+	"tx_metalabels":      http.MethodGet,
+	"submittx":           http.MethodPost,
+	"tx_status":          http.MethodPost,
+	"address_info":       http.MethodGet,
+	"address_txs":        http.MethodPost,
+	"credential_txs":     http.MethodPost,
+	"address_assets":     http.MethodGet,
+	"account_list":       http.MethodGet,
+	"account_info":       http.MethodGet,
+	"account_rewards":    http.MethodGet,
+	"account_updates":    http.MethodGet,
+	"account_addresses":  http.MethodGet,
+	"account_assets":     http.MethodGet,
+	"account_history":    http.MethodGet,
+	"asset_list":         http.MethodGet,
+	"asset_address_list": http.MethodGet,
+	"asset_info":         http.MethodGet,
+	"asset_summary":      http.MethodGet,
+	"asset_txs":          http.MethodGet,
+	"pool_list":          http.MethodGet,
+	"pool_info":          http.MethodPost,
+	"pool_delegators":    http.MethodGet,
+	"pool_blocks":        http.MethodGet,
+	"pool_updates":       http.MethodGet,
+	"pool_relays":        http.MethodGet,
+	"pool_metadata":      http.MethodGet,
+	"script_list":        http.MethodGet,
+	"script_redeemers":   http.MethodGet,
+}
+
+// paramColumns maps RPC parameters to columns of fixture rows
+// used to filter the rows.
+var paramColumns = map[string][]string{
+	"_address":             {"address", "stake_address"},
+	"_addresses":           {"address"},
+	"_stake_address":       {"stake_address"},
+	"_epoch_no":            {"epoch_no"},
+	"_block_hash":          {"block_hash", "hash"},
+	"_tx_hashes":           {"tx_hash"},
+	"_asset_policy":        {"policy_id", "asset_policy", "asset_policy_hex"},
+	"_asset_name":          {"asset_name", "asset_name_hex"},
+	"_pool_bech32":         {"pool_id_bech32"},
+	"_pool_bech32_ids":     {"pool_id_bech32"},
+	"_script_hash":         {"script_hash"},
+	"_payment_credentials": {"payment_cred"},
+}
+
+type (
+	// Server is fake Koios server.
+	Server struct {
+		*httptest.Server
+
+		mux      sync.Mutex
+		fixtures map[string]json.RawMessage
+		faults   map[string]*Fault
+		latency  map[string]time.Duration
+		requests map[string]int
+	}
+
+	// Fault is error injected to responses of the endpoint.
+	Fault struct {
+		// StatusCode of the response e.g. 429, 503.
+		StatusCode int
+
+		// Message of the error response body.
+		Message string
+
+		// Header is added to the response e.g. Retry-After.
+		Header http.Header
+
+		// Times is number of responses affected by the fault,
+		// 0 means until fault is cleared.
+		Times int
+	}
+
+	// postgrestError is error response body returned by PostgREST.
+	postgrestError struct {
+		Code    string `json:"code,omitempty"`
+		Message string `json:"message"`
+		Hint    string `json:"hint,omitempty"`
+	}
+)
+
+// NewServer starts and returns new Server serving default fixtures.
+// Caller should call Close when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{
+		fixtures: make(map[string]json.RawMessage),
+		faults:   make(map[string]*Fault),
+		latency:  make(map[string]time.Duration),
+		requests: make(map[string]int),
+	}
+	sub, err := fs.Sub(defaultFixtures, "fixtures")
+	if err != nil {
+		panic(err)
+	}
+	if err := s.LoadFixturesFS(sub); err != nil {
+		panic(err)
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Client returns API client configured to use the server.
+// Rate limit is disabled, provided options are applied after the defaults.
+func (s *Server) Client(opts ...koios.Option) (*koios.Client, error) {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseUint(u.Port(), 10, 16)
+	if err != nil {
+		return nil, err
+	}
+	return koios.New(append([]koios.Option{
+		koios.Schema(u.Scheme),
+		koios.Host(u.Hostname()),
+		koios.Port(uint16(port)),
+		koios.RateLimiter(koios.NewTokenBucket(0, 0)),
+	}, opts...)...)
+}
+
+// LoadFixtures loads fixtures from <endpoint>.json files in dir
+// replacing fixtures of these endpoints.
+func (s *Server) LoadFixtures(dir string) error {
+	return s.LoadFixturesFS(os.DirFS(dir))
+}
+
+// LoadFixturesFS loads fixtures from <endpoint>.json files in root
+// of fsys replacing fixtures of these endpoints.
+func (s *Server) LoadFixturesFS(fsys fs.FS) error {
+	files, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+		endpoint := strings.TrimSuffix(filepath.Base(file), ".json")
+		if err := s.SetFixture(endpoint, json.RawMessage(data)); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// SetFixture sets data served by endpoint. Data is encoded as JSON,
+// use json.RawMessage to set already encoded data. Fixtures of list
+// endpoints should be arrays of rows.
+func (s *Server) SetFixture(endpoint string, data interface{}) error {
+	endpoint = strings.Trim(endpoint, "/")
+	if _, ok := Endpoints[endpoint]; !ok {
+		return fmt.Errorf("koiostest: unknown endpoint %q", endpoint)
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.fixtures[endpoint] = raw
+	return nil
+}
+
+// InjectFault makes endpoint respond with error described by fault.
+// Use "*" as endpoint to affect all endpoints.
+func (s *Server) InjectFault(endpoint string, fault Fault) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	f := fault
+	s.faults[strings.Trim(endpoint, "/")] = &f
+}
+
+// ClearFaults removes all injected faults.
+func (s *Server) ClearFaults() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.faults = make(map[string]*Fault)
+}
+
+// SetLatency delays responses of endpoint by d.
+// Use "*" as endpoint to delay all endpoints.
+func (s *Server) SetLatency(endpoint string, d time.Duration) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.latency[strings.Trim(endpoint, "/")] = d
+}
+
+// Requests returns number of requests received by endpoint.
+func (s *Server) Requests(endpoint string) int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.requests[strings.Trim(endpoint, "/")]
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	endpoint := r.URL.Path
+	if i := strings.Index(endpoint, "/api/"); i != -1 {
+		endpoint = endpoint[i+len("/api/"):]
+		if j := strings.IndexByte(endpoint, '/'); j != -1 {
+			endpoint = endpoint[j+1:]
+		}
+	}
+	endpoint = strings.Trim(endpoint, "/")
+
+	s.mux.Lock()
+	s.requests[endpoint]++
+	fault := s.fault(endpoint)
+	latency, ok := s.latency[endpoint]
+	if !ok {
+		latency = s.latency["*"]
+	}
+	fixture := s.fixtures[endpoint]
+	s.mux.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	method, ok := Endpoints[endpoint]
+	switch {
+	case !ok:
+		writeError(w, http.StatusNotFound, postgrestError{
+			Code:    "PGRST202",
+			Message: fmt.Sprintf("Could not find the function %s", endpoint),
+		})
+		return
+	case fault != nil:
+		for name, values := range fault.Header {
+			for _, value := range values {
+				w.Header().Add(name, value)
+			}
+		}
+		msg := fault.Message
+		if len(msg) == 0 {
+			msg = http.StatusText(fault.StatusCode)
+		}
+		writeError(w, fault.StatusCode, postgrestError{Message: msg})
+		return
+	case r.Method != method && !(r.Method == http.MethodHead && method == http.MethodGet):
+		writeError(w, http.StatusMethodNotAllowed, postgrestError{
+			Message: fmt.Sprintf("%s is not allowed for %s", r.Method, endpoint),
+		})
+		return
+	}
+
+	params, err := requestParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, postgrestError{Code: "PGRST102", Message: err.Error()})
+		return
+	}
+
+	if endpoint == "submittx" {
+		s.submitTx(w, params, fixture)
+		return
+	}
+
+	var rows []map[string]interface{}
+	if len(fixture) > 0 {
+		if err := json.Unmarshal(fixture, &rows); err != nil {
+			// not a list, serve fixture as is.
+			writeJSON(w, http.StatusOK, fixture)
+			return
+		}
+	}
+	rows = filterRows(rows, params)
+
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit > MaxRows || limit < 0 {
+		limit = MaxRows
+	}
+	total := len(rows)
+	if offset > total || offset < 0 {
+		offset = total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	page := rows[offset:end]
+
+	count := "*"
+	if strings.Contains(r.Header.Get("Prefer"), "count=exact") {
+		count = strconv.Itoa(total)
+	}
+	if len(page) == 0 {
+		w.Header().Set("Content-Range", "*/"+count)
+	} else {
+		w.Header().Set("Content-Range", fmt.Sprintf("%d-%d/%s", offset, end-1, count))
+	}
+
+	if page == nil {
+		page = []map[string]interface{}{}
+	}
+	body, err := json.Marshal(page)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, postgrestError{Message: err.Error()})
+		return
+	}
+	if r.Method == http.MethodHead {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// fault returns active fault of the endpoint, caller must hold the lock.
+func (s *Server) fault(endpoint string) *Fault {
+	for _, key := range []string{endpoint, "*"} {
+		f, ok := s.faults[key]
+		if !ok {
+			continue
+		}
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				delete(s.faults, key)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+// submitTx responds with hash of submitted transaction
+// or with fixture when set.
+func (s *Server) submitTx(w http.ResponseWriter, params map[string]interface{}, fixture json.RawMessage) {
+	cbor, _ := params["cbor"].([]byte)
+	if len(cbor) == 0 {
+		writeError(w, http.StatusBadRequest, postgrestError{Message: "missing transaction cbor"})
+		return
+	}
+	if len(fixture) > 0 {
+		writeJSON(w, http.StatusAccepted, fixture)
+		return
+	}
+	sum := sha256.Sum256(cbor)
+	body, _ := json.Marshal(hex.EncodeToString(sum[:]))
+	writeJSON(w, http.StatusAccepted, body)
+}
+
+// requestParams returns RPC parameters from query and JSON payload.
+func requestParams(r *http.Request) (map[string]interface{}, error) {
+	params := make(map[string]interface{})
+	for key, values := range r.URL.Query() {
+		if strings.HasPrefix(key, "_") && len(values) > 0 {
+			params[key] = values[0]
+		}
+	}
+	if r.Body == nil || r.Method != http.MethodPost {
+		return params, nil
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, 10<<20))
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(r.Header.Get("Content-Type"), "cbor") {
+		params["cbor"] = body
+		return params, nil
+	}
+	if len(body) == 0 {
+		return params, nil
+	}
+	if err := json.Unmarshal(body, &params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// filterRows returns rows matching RPC parameters. Parameter is applied
+// only to rows which have column corresponding to the parameter.
+func filterRows(rows []map[string]interface{}, params map[string]interface{}) []map[string]interface{} {
+	var filtered []map[string]interface{}
+	for _, row := range rows {
+		if matchRow(row, params) {
+			filtered = append(filtered, row)
+		}
+	}
+	return filtered
+}
+
+func matchRow(row map[string]interface{}, params map[string]interface{}) bool {
+	for param, value := range params {
+		for _, column := range paramColumns[param] {
+			v, ok := row[column]
+			if !ok {
+				continue
+			}
+			if !matchValue(v, value) {
+				return false
+			}
+			break
+		}
+	}
+	return true
+}
+
+// matchValue reports whether column value v equals param value
+// or is one of the values when param is a list.
+func matchValue(v, param interface{}) bool {
+	if list, ok := param.([]interface{}); ok {
+		for _, p := range list {
+			if fmt.Sprint(v) == fmt.Sprint(p) {
+				return true
+			}
+		}
+		return false
+	}
+	return fmt.Sprint(v) == fmt.Sprint(param)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body []byte) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+func writeError(w http.ResponseWriter, status int, perr postgrestError) {
+	body, _ := json.Marshal(perr)
+	writeJSON(w, status, body)
+}
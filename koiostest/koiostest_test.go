@@ -0,0 +1,118 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koiostest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/howijd/koios-rest-go-client"
+	"github.com/howijd/koios-rest-go-client/koiostest"
+)
+
+func newServer(t *testing.T) (*koiostest.Server, *koios.Client) {
+	t.Helper()
+	srv := koiostest.NewServer()
+	t.Cleanup(srv.Close)
+	api, err := srv.Client()
+	assert.NoError(t, err)
+	return srv, api
+}
+
+func TestServerFixtures(t *testing.T) {
+	srv, api := newServer(t)
+	ctx := context.Background()
+
+	tip, err := api.GetTip(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, tip.StatusCode)
+	if assert.NotNil(t, tip.Data) {
+		assert.NotZero(t, tip.Data.BlockNo)
+	}
+
+	txs, err := api.GetTxsInfos(ctx, []koios.TxHash{
+		"f144a8264acf4bdfe2e1241170969c930d64ab6b0996a4a45237b623f1dd670e",
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, txs.Data, 1) {
+		assert.Equal(t, koios.TxHash("f144a8264acf4bdfe2e1241170969c930d64ab6b0996a4a45237b623f1dd670e"), txs.Data[0].TxHash)
+	}
+
+	submitted, err := api.SubmitSignedTx(ctx, koios.TxBodyJSON{CborHex: "84a300"})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, submitted.StatusCode)
+	assert.Len(t, string(submitted.Data), 64)
+
+	assert.Equal(t, 1, srv.Requests("tip"))
+	assert.Equal(t, 1, srv.Requests("submittx"))
+}
+
+func TestServerPagination(t *testing.T) {
+	srv, api := newServer(t)
+	const total = 2500
+	var pools []koios.PoolListItem
+	for i := 0; i < total; i++ {
+		pools = append(pools, koios.PoolListItem{PoolID: koios.PoolID(fmt.Sprint("pool", i))})
+	}
+	assert.NoError(t, srv.SetFixture("pool_list", pools))
+
+	it := api.PoolListIterator(context.Background())
+	count := 0
+	for it.Next() {
+		assert.Equal(t, koios.PoolID(fmt.Sprint("pool", count)), it.Value().PoolID)
+		count++
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, total, count)
+	// total is unknown, iteration ends with empty page.
+	assert.Equal(t, 4, srv.Requests("pool_list"))
+
+	it = api.PoolListIterator(context.Background(), koios.WithExactCount())
+	count = 0
+	for it.Next() {
+		count++
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, total, count)
+	assert.Equal(t, 7, srv.Requests("pool_list"))
+}
+
+func TestServerFaults(t *testing.T) {
+	srv, api := newServer(t)
+	ctx := context.Background()
+
+	srv.InjectFault("tip", koiostest.Fault{StatusCode: http.StatusServiceUnavailable, Times: 1})
+	_, err := api.GetTip(ctx)
+	assert.True(t, errors.Is(err, koios.ErrServerUnavailable), "expected server unavailable got: %v", err)
+
+	_, err = api.GetTip(ctx)
+	assert.NoError(t, err, "fault should be cleared after one response")
+
+	srv.SetLatency("*", 200*time.Millisecond)
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, err = api.GetTip(ctx)
+	assert.Error(t, err)
+
+	assert.Error(t, srv.SetFixture("unknown", []string{}))
+}
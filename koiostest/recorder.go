@@ -0,0 +1,363 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koiostest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Replay        : serves recorded responses only, unmatched request fails.
+// Record        : sends requests upstream and records responses.
+// ReplayOrRecord: serves recorded responses, unmatched requests are
+// sent upstream and recorded.
+const (
+	Replay Mode = iota
+	Record
+	ReplayOrRecord
+)
+
+// Strict : request matches when method, path, query and body are equal.
+// Lenient: when there is no exact match, recording of the same method and
+// path with most equal query parameters and equivalent JSON body is used.
+const (
+	Strict Matching = iota
+	Lenient
+)
+
+// ErrNoInteraction is returned by Recorder when there is no recorded
+// response for the request in Replay mode.
+var ErrNoInteraction = errors.New("koiostest: no recorded interaction")
+
+type (
+	// Mode of the Recorder.
+	Mode uint8
+
+	// Matching defines how requests are matched with recorded interactions.
+	Matching uint8
+
+	// Recorder is http.RoundTripper which records responses of Koios
+	// instance to cassette files and replays them later.
+	//
+	// Each cassette file is keyed by request method, path, query and body,
+	// repeated requests with the same key are replayed in recorded order
+	// and the last recorded response is repeated afterwards.
+	Recorder struct {
+		// Matching of requests, defaults to Strict.
+		Matching Matching
+
+		// Transport used to send requests in Record and ReplayOrRecord
+		// mode, http.DefaultTransport is used when nil.
+		Transport http.RoundTripper
+
+		mux       sync.Mutex
+		dir       string
+		mode      Mode
+		cassettes map[string]*cassette
+	}
+
+	// Interaction is recorded request and response.
+	Interaction struct {
+		Request  RecordedRequest  `json:"request"`
+		Response RecordedResponse `json:"response"`
+	}
+
+	// RecordedRequest is request of the Interaction.
+	RecordedRequest struct {
+		Method string `json:"method"`
+		Path   string `json:"path"`
+		Query  string `json:"query,omitempty"`
+		Body   string `json:"body,omitempty"`
+	}
+
+	// RecordedResponse is response of the Interaction.
+	RecordedResponse struct {
+		StatusCode int         `json:"status_code"`
+		Header     http.Header `json:"header"`
+		Body       string      `json:"body"`
+
+		// Base64 is true when body is not valid UTF-8 and is base64 encoded.
+		Base64 bool `json:"base64,omitempty"`
+	}
+
+	cassette struct {
+		file         string
+		interactions []Interaction
+		pos          int
+		recorded     bool
+	}
+)
+
+// NewRecorder returns Recorder storing cassettes in dir.
+// Existing cassettes are loaded unless mode is Record.
+func NewRecorder(dir string, mode Mode) (*Recorder, error) {
+	r := &Recorder{
+		dir:       dir,
+		mode:      mode,
+		cassettes: make(map[string]*cassette),
+	}
+	if mode == Record {
+		return r, os.MkdirAll(dir, 0o755)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		cas := &cassette{file: file}
+		if err := json.Unmarshal(data, &cas.interactions); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if len(cas.interactions) == 0 {
+			continue
+		}
+		req := cas.interactions[0].Request
+		r.cassettes[interactionKey(req.Method, req.Path, req.Query, []byte(req.Body))] = cas
+	}
+	return r, nil
+}
+
+// Client returns http.Client using the Recorder
+// which can be used with koios.HTTPClient option.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{
+		Timeout:   time.Minute,
+		Transport: r,
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	query := req.URL.Query().Encode()
+	key := interactionKey(req.Method, req.URL.Path, query, body)
+
+	if r.mode != Record {
+		if in, ok := r.replay(key, req.Method, req.URL.Path, query, body); ok {
+			return in.Response.response(req)
+		}
+		if r.mode == Replay {
+			return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, req.Method, req.URL.RequestURI())
+		}
+	}
+	return r.record(key, req, query, body)
+}
+
+func (r *Recorder) replay(key, method, p, query string, body []byte) (Interaction, bool) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	cas, ok := r.cassettes[key]
+	if !ok && r.Matching == Lenient {
+		cas = r.closest(method, p, query, body)
+	}
+	if cas == nil || len(cas.interactions) == 0 {
+		return Interaction{}, false
+	}
+	in := cas.interactions[cas.pos]
+	if cas.pos < len(cas.interactions)-1 {
+		cas.pos++
+	}
+	return in, true
+}
+
+// closest returns cassette of the same method and path with most
+// equal query parameters, equal JSON bodies are preferred.
+// Caller must hold the lock.
+func (r *Recorder) closest(method, p, query string, body []byte) *cassette {
+	params := parseQuery(query)
+	norm := normalizeJSON(body)
+	var (
+		best      *cassette
+		bestScore = -1
+	)
+	for _, cas := range r.cassettes {
+		req := cas.interactions[0].Request
+		if req.Method != method || req.Path != p {
+			continue
+		}
+		score := 0
+		for key, value := range parseQuery(req.Query) {
+			if params[key] == value {
+				score++
+			}
+		}
+		if normalizeJSON([]byte(req.Body)) == norm {
+			score += len(params) + 1
+		}
+		// prefer deterministic choice between equal scores.
+		if score > bestScore || (score == bestScore && cas.file < best.file) {
+			best, bestScore = cas, score
+		}
+	}
+	return best
+}
+
+func (r *Recorder) record(key string, req *http.Request, query string, body []byte) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	rsp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	rspBody, err := ioutil.ReadAll(rsp.Body)
+	_ = rsp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	rsp.Body = ioutil.NopCloser(bytes.NewReader(rspBody))
+
+	in := Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			Path:   req.URL.Path,
+			Query:  query,
+			Body:   string(body),
+		},
+		Response: RecordedResponse{
+			StatusCode: rsp.StatusCode,
+			Header:     rsp.Header.Clone(),
+		},
+	}
+	if utf8.Valid(rspBody) {
+		in.Response.Body = string(rspBody)
+	} else {
+		in.Response.Body = base64.StdEncoding.EncodeToString(rspBody)
+		in.Response.Base64 = true
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	cas, ok := r.cassettes[key]
+	if !ok {
+		cas = &cassette{file: filepath.Join(r.dir, cassetteName(req.Method, req.URL.Path, key))}
+		r.cassettes[key] = cas
+	}
+	if !cas.recorded {
+		// first recording in this session replaces previous recordings.
+		cas.interactions, cas.pos, cas.recorded = nil, 0, true
+	}
+	cas.interactions = append(cas.interactions, in)
+	data, err := json.MarshalIndent(cas.interactions, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(cas.file, data, 0o600); err != nil {
+		return nil, err
+	}
+	return rsp, nil
+}
+
+func (rr RecordedResponse) response(req *http.Request) (*http.Response, error) {
+	body := []byte(rr.Body)
+	if rr.Base64 {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(rr.Body); err != nil {
+			return nil, err
+		}
+	}
+	header := rr.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rr.StatusCode, http.StatusText(rr.StatusCode)),
+		StatusCode:    rr.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func interactionKey(method, p, query string, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%s\n", method, p, query)
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cassetteName returns file name of cassette e.g. get_tip_1a2b3c4d5e6f7a8b.json.
+func cassetteName(method, p, key string) string {
+	endpoint := strings.Trim(path.Base(p), "/.")
+	if len(endpoint) == 0 {
+		endpoint = "root"
+	}
+	return fmt.Sprintf("%s_%s_%s.json", strings.ToLower(method), endpoint, key[:16])
+}
+
+func parseQuery(query string) map[string]string {
+	values := make(map[string]string)
+	for _, pair := range strings.Split(query, "&") {
+		if len(pair) == 0 {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) == 2 {
+			values[kv[0]] = kv[1]
+		} else {
+			values[kv[0]] = ""
+		}
+	}
+	return values
+}
+
+// normalizeJSON returns body with JSON formatting and key order
+// normalized or body as is when it is not JSON.
+func normalizeJSON(body []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return string(body)
+	}
+	norm, err := json.Marshal(v)
+	if err != nil {
+		return string(body)
+	}
+	return string(norm)
+}
@@ -0,0 +1,68 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koiostest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/howijd/koios-rest-go-client"
+	"github.com/howijd/koios-rest-go-client/koiostest"
+)
+
+func TestRecorder(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	tx := koios.TxHash("f144a8264acf4bdfe2e1241170969c930d64ab6b0996a4a45237b623f1dd670e")
+
+	srv := koiostest.NewServer()
+	rec, err := koiostest.NewRecorder(dir, koiostest.Record)
+	assert.NoError(t, err)
+	api, err := srv.Client(koios.HTTPClient(rec.Client()))
+	assert.NoError(t, err)
+
+	tip, err := api.GetTip(ctx)
+	assert.NoError(t, err)
+	txs, err := api.GetTxsInfos(ctx, []koios.TxHash{tx})
+	assert.NoError(t, err)
+	srv.Close()
+
+	rec, err = koiostest.NewRecorder(dir, koiostest.Replay)
+	assert.NoError(t, err)
+	api, err = srv.Client(koios.HTTPClient(rec.Client()))
+	assert.NoError(t, err)
+
+	replayedTip, err := api.GetTip(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, tip.Data, replayedTip.Data)
+	replayedTxs, err := api.GetTxsInfos(ctx, []koios.TxHash{tx})
+	assert.NoError(t, err)
+	assert.Equal(t, txs.Data, replayedTxs.Data)
+
+	// strict matching fails on unknown request.
+	_, err = api.GetTxsInfos(ctx, []koios.TxHash{"unknown"})
+	assert.True(t, errors.Is(err, koiostest.ErrNoInteraction), "expected no interaction got: %v", err)
+
+	// lenient matching falls back to recording of the same endpoint.
+	rec.Matching = koiostest.Lenient
+	replayedTxs, err = api.GetTxsInfos(ctx, []koios.TxHash{"unknown"})
+	assert.NoError(t, err)
+	assert.Equal(t, txs.Data, replayedTxs.Data)
+}
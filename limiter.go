@@ -0,0 +1,108 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+type (
+	// Limiter is used by API client to rate limit outgoing requests.
+	// Implementation must be safe for concurrent use.
+	Limiter interface {
+		// Wait blocks until request is allowed to be sent or ctx is done,
+		// in which case ctx.Err() is returned.
+		Wait(ctx context.Context) error
+	}
+
+	// TokenBucket is Limiter implementing token bucket algorithm.
+	// Bucket holds up to burst tokens and is refilled with rate
+	// tokens per second, each request consumes single token.
+	TokenBucket struct {
+		mux    sync.Mutex
+		rate   float64
+		burst  float64
+		tokens float64
+		last   time.Time
+	}
+)
+
+// NewTokenBucket returns token bucket Limiter allowing rate requests
+// per second with bursts of up to burst requests. Burst less than 1
+// is treated as 1 and rate <= 0 disables limiting.
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	if rate <= 0 {
+		rate = math.Inf(1)
+	}
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// Wait implements Limiter interface. Calls to Wait reserve a token
+// and callers are served in order they called Wait. Reserved token
+// is returned to the bucket when ctx is done before token was available.
+func (tb *TokenBucket) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if math.IsInf(tb.rate, 1) {
+		return nil
+	}
+	tb.mux.Lock()
+	tb.refill(time.Now())
+	tb.tokens--
+	var delay time.Duration
+	if tb.tokens < 0 {
+		delay = time.Duration(-tb.tokens / tb.rate * float64(time.Second))
+	}
+	tb.mux.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		tb.mux.Lock()
+		tb.tokens++
+		tb.mux.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (tb *TokenBucket) refill(now time.Time) {
+	if !tb.last.IsZero() {
+		tb.tokens += now.Sub(tb.last).Seconds() * tb.rate
+		if tb.tokens > tb.burst {
+			tb.tokens = tb.burst
+		}
+	}
+	tb.last = now
+}
@@ -0,0 +1,97 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/howijd/koios-rest-go-client"
+)
+
+func TestTokenBucketBurst(t *testing.T) {
+	tb := koios.NewTokenBucket(20, 3)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, tb.Wait(ctx))
+	}
+	assert.Less(t, time.Since(start), 25*time.Millisecond, "burst should not wait")
+
+	assert.NoError(t, tb.Wait(ctx))
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond, "request after burst should wait")
+}
+
+func TestTokenBucketContextCancel(t *testing.T) {
+	tb := koios.NewTokenBucket(1, 1)
+	assert.NoError(t, tb.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := tb.Wait(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "wait should be aborted with ctx")
+}
+
+func TestRateLimiterOption(t *testing.T) {
+	_, err := koios.New(koios.RateLimiter(nil))
+	assert.ErrorIs(t, err, koios.ErrLimiterNil)
+
+	api, err := koios.New(koios.RateLimiter(koios.NewTokenBucket(10, 10)))
+	assert.NoError(t, err)
+	assert.NotNil(t, api)
+}
+
+type failingLimiter struct{}
+
+func (failingLimiter) Wait(ctx context.Context) error {
+	return errors.New("limited")
+}
+
+func TestLimiterErrorClosesBody(t *testing.T) {
+	api := newTestClient(t, http.NotFoundHandler(),
+		koios.RateLimiter(failingLimiter{}),
+		koios.Instrumentation(koios.NewMetrics()),
+	)
+	before := runtime.NumGoroutine()
+	for i := 0; i < 50; i++ {
+		_, err := api.GetTxsUTxOs(context.Background(), []koios.TxHash{"tx"})
+		assert.Error(t, err)
+	}
+	assertGoroutinesExit(t, before)
+	assert.Equal(t, uint64(0), api.TotalRequests())
+}
+
+// assertGoroutinesExit checks that goroutines started since before
+// was taken e.g. ones writing request payloads have exited.
+func assertGoroutinesExit(t *testing.T, before int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before+5 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before+5)
+}
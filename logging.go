@@ -0,0 +1,112 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios
+
+import (
+	"context"
+	"net/http"
+	"unicode/utf8"
+)
+
+// maxLoggedBody is maximum number of bytes of the response body
+// included in log records.
+const maxLoggedBody = 512
+
+type (
+	// Logger is structured logger used by the API client.
+	// *slog.Logger implements it.
+	Logger interface {
+		DebugContext(ctx context.Context, msg string, args ...any)
+		InfoContext(ctx context.Context, msg string, args ...any)
+		WarnContext(ctx context.Context, msg string, args ...any)
+		ErrorContext(ctx context.Context, msg string, args ...any)
+	}
+)
+
+// redactedHeaders are request headers which values are not logged.
+var redactedHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization", "X-Api-Key"}
+
+// Logging sets logger used to log requests. Start and finish of requests,
+// rate limit and queue waits are logged at debug level, retries and
+// failovers at info level, error responses at warn level and failures
+// to decode response at error level. Values of headers carrying
+// credentials are redacted.
+func Logging(logger Logger) Option {
+	return func(c *Client) error {
+		if logger == nil {
+			return ErrLoggerNil
+		}
+		c.mux.Lock()
+		defer c.mux.Unlock()
+		c.logger = logger
+		return nil
+	}
+}
+
+// redactHeaders returns copy of h with credentials redacted.
+func redactHeaders(h http.Header) http.Header {
+	redacted := h.Clone()
+	for _, name := range redactedHeaders {
+		if len(redacted.Values(name)) > 0 {
+			redacted.Set(name, "[REDACTED]")
+		}
+	}
+	return redacted
+}
+
+// bodyExcerpt returns body truncated to maxLoggedBody bytes.
+func bodyExcerpt(body []byte) string {
+	if len(body) <= maxLoggedBody {
+		return string(body)
+	}
+	cut := maxLoggedBody
+	// do not split multibyte character.
+	for cut > 0 && !utf8.RuneStart(body[cut]) {
+		cut--
+	}
+	return string(body[:cut]) + "...(truncated)"
+}
+
+// logResponseError logs failed response and its body excerpt.
+func (r *Response) logResponseError(ctx context.Context, body []byte, err error) {
+	if r.logger == nil {
+		return
+	}
+	args := []any{
+		"method", r.RequestMethod,
+		"url", r.RequestURL,
+		"status", r.StatusCode,
+		"body", bodyExcerpt(body),
+	}
+	if err != nil {
+		r.logger.ErrorContext(ctx, "koios: failed to decode response", append(args, "error", err)...)
+		return
+	}
+	r.logger.WarnContext(ctx, "koios: error response", args...)
+}
+
+// attemptAttrs returns log attributes describing result of the attempt.
+func attemptAttrs(call *Call, rsp *http.Response, err error) []any {
+	args := []any{"endpoint", call.Endpoint, "attempt", call.Attempt}
+	if rsp != nil {
+		args = append(args, "status", rsp.StatusCode)
+	}
+	if err != nil {
+		args = append(args, "error", err)
+	}
+	return args
+}
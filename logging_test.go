@@ -0,0 +1,162 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/howijd/koios-rest-go-client"
+)
+
+type logRecord struct {
+	level string
+	msg   string
+	attrs map[string]any
+}
+
+type testLogger struct {
+	mu      sync.Mutex
+	records []logRecord
+}
+
+func (l *testLogger) log(level, msg string, args []any) {
+	attrs := make(map[string]any)
+	for i := 0; i+1 < len(args); i += 2 {
+		attrs[args[i].(string)] = args[i+1]
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, logRecord{level: level, msg: msg, attrs: attrs})
+}
+
+func (l *testLogger) DebugContext(ctx context.Context, msg string, args ...any) {
+	l.log("debug", msg, args)
+}
+
+func (l *testLogger) InfoContext(ctx context.Context, msg string, args ...any) {
+	l.log("info", msg, args)
+}
+
+func (l *testLogger) WarnContext(ctx context.Context, msg string, args ...any) {
+	l.log("warn", msg, args)
+}
+
+func (l *testLogger) ErrorContext(ctx context.Context, msg string, args ...any) {
+	l.log("error", msg, args)
+}
+
+func (l *testLogger) find(msg string) []logRecord {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var found []logRecord
+	for _, r := range l.records {
+		if r.msg == msg {
+			found = append(found, r)
+		}
+	}
+	return found
+}
+
+func TestLogging(t *testing.T) {
+	logger := &testLogger{}
+	token := newToken(1, time.Now().Add(30*24*time.Hour))
+	api := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("[]"))
+	}), koios.Logging(logger), koios.Auth(token))
+
+	_, err := api.GetAccountList(context.Background())
+	assert.NoError(t, err)
+
+	reqs := logger.find("koios: request")
+	if assert.Len(t, reqs, 1) {
+		assert.Equal(t, "debug", reqs[0].level)
+		assert.Equal(t, "GET", reqs[0].attrs["method"])
+		headers := reqs[0].attrs["headers"].(http.Header)
+		assert.Equal(t, "[REDACTED]", headers.Get("Authorization"))
+		assert.Equal(t, "application/json", headers.Get("Accept"))
+	}
+	rsps := logger.find("koios: response")
+	if assert.Len(t, rsps, 1) {
+		assert.Equal(t, "debug", rsps[0].level)
+		assert.Equal(t, "account_list", rsps[0].attrs["endpoint"])
+		assert.Equal(t, http.StatusOK, rsps[0].attrs["status"])
+	}
+
+	_, err = koios.New(koios.Logging(nil))
+	assert.ErrorIs(t, err, koios.ErrLoggerNil)
+}
+
+func TestLoggingFailures(t *testing.T) {
+	logger := &testLogger{}
+	var calls int
+	api := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		calls++
+		switch {
+		case r.URL.Path == "/api/v0/tip":
+			_, _ = w.Write([]byte(`{"broken": ` + strings.Repeat("x", 1000)))
+		case calls == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"message":"` + strings.Repeat("é", 600) + `"}`))
+		}
+	}),
+		koios.Logging(logger),
+		koios.Retry(koios.RetryPolicy{
+			MaxAttempts: 2,
+			MinBackoff:  time.Millisecond,
+			RetryOn:     []int{http.StatusServiceUnavailable},
+		}),
+	)
+
+	_, err := api.GetAccountList(context.Background())
+	assert.ErrorIs(t, err, koios.ErrBadRequest)
+
+	retries := logger.find("koios: retrying request")
+	if assert.Len(t, retries, 1) {
+		assert.Equal(t, "info", retries[0].level)
+		assert.Equal(t, http.StatusServiceUnavailable, retries[0].attrs["status"])
+		assert.Equal(t, time.Millisecond, retries[0].attrs["delay"])
+	}
+	errs := logger.find("koios: error response")
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, "warn", errs[0].level)
+		assert.Equal(t, http.StatusBadRequest, errs[0].attrs["status"])
+		body := errs[0].attrs["body"].(string)
+		assert.True(t, strings.HasSuffix(body, "...(truncated)"))
+		assert.LessOrEqual(t, len(body), 512+len("...(truncated)"))
+		assert.True(t, strings.HasPrefix(body, `{"message":"é`))
+	}
+
+	_, err = api.GetTip(context.Background())
+	assert.Error(t, err)
+	decode := logger.find("koios: failed to decode response")
+	if assert.Len(t, decode, 1) {
+		assert.Equal(t, "error", decode[0].level)
+		assert.NotNil(t, decode[0].attrs["error"])
+		assert.True(t, strings.HasPrefix(decode[0].attrs["body"].(string), `{"broken": xxx`))
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios
+
+import (
+	"net/http"
+	"time"
+)
+
+type (
+	// Call is single API request attempt passed through middleware chain.
+	Call struct {
+		// Endpoint is relative API path e.g. "tip", "tx_info".
+		Endpoint string
+
+		// Attempt is number of the attempt starting from 1,
+		// incremented on retries and failovers to other instance.
+		Attempt int
+
+		// Priority of the call in the queue of MaxConcurrentRequests.
+		Priority Priority
+
+		// Request is HTTP request about to be sent.
+		Request *http.Request
+
+		// Response is metadata of the API response, it is populated
+		// when next RoundTripFunc returns. Response is nil for requests
+		// made with GET, POST and HEAD methods of the Client.
+		Response *Response
+
+		// queueWait is time the attempt waited for free slot.
+		queueWait time.Duration
+
+		// sent reports whether the attempt reached the transport.
+		sent bool
+
+		// latency is duration of the round trip excluding
+		// waiting for free slot and rate limit.
+		latency time.Duration
+	}
+
+	// RoundTripFunc sends the call and returns an HTTP response.
+	RoundTripFunc func(call *Call) (*http.Response, error)
+
+	// Middleware wraps RoundTripFunc e.g. to modify request,
+	// inspect response or short circuit the call.
+	Middleware func(next RoundTripFunc) RoundTripFunc
+)
+
+// Middlewares adds middlewares which are applied to every request
+// sent by the API client. First middleware is the outermost one.
+// Middlewares are applied on every attempt after rate limit is applied.
+func Middlewares(mws ...Middleware) Option {
+	return func(c *Client) error {
+		c.mux.Lock()
+		defer c.mux.Unlock()
+		for _, mw := range mws {
+			if mw == nil {
+				return ErrMiddlewareNil
+			}
+			c.middlewares = append(c.middlewares, mw)
+		}
+		return nil
+	}
+}
+
+// chain wraps rt with middlewares.
+func chain(rt RoundTripFunc, mws []Middleware) RoundTripFunc {
+	for i := len(mws) - 1; i >= 0; i-- {
+		rt = mws[i](rt)
+	}
+	return rt
+}
@@ -0,0 +1,71 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/howijd/koios-rest-go-client"
+)
+
+func TestMiddlewares(t *testing.T) {
+	var (
+		endpoint string
+		status   int
+	)
+	tag := func(name string) koios.Middleware {
+		return func(next koios.RoundTripFunc) koios.RoundTripFunc {
+			return func(call *koios.Call) (*http.Response, error) {
+				trace := name
+				if v := call.Request.Header.Get("X-Trace"); v != "" {
+					trace = v + "," + name
+				}
+				call.Request.Header.Set("X-Trace", trace)
+				return next(call)
+			}
+		}
+	}
+	observe := func(next koios.RoundTripFunc) koios.RoundTripFunc {
+		return func(call *koios.Call) (*http.Response, error) {
+			rsp, err := next(call)
+			endpoint = call.Endpoint
+			status = call.Response.StatusCode
+			return rsp, err
+		}
+	}
+
+	api := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "outer,inner", r.Header.Get("X-Trace"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"block_no":1}]`))
+	}), koios.Middlewares(observe, tag("outer"), tag("inner")))
+
+	// order of the chain is same on every call.
+	for i := 0; i < 2; i++ {
+		_, err := api.GetTip(context.Background())
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, "tip", endpoint)
+	assert.Equal(t, http.StatusOK, status)
+
+	_, err := koios.New(koios.Middlewares(nil))
+	assert.ErrorIs(t, err, koios.ErrMiddlewareNil)
+}
@@ -18,7 +18,6 @@ package koios
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/url"
 )
@@ -137,89 +136,87 @@ type (
 )
 
 // GetTip returns the tip info about the latest block seen by chain.
-func (c *Client) GetTip(ctx context.Context) (res *TipResponse, err error) {
+func (c *Client) GetTip(ctx context.Context, opts ...CallOption) (res *TipResponse, err error) {
 	res = &TipResponse{}
-	rsp, err := c.request(ctx, &res.Response, "GET", "/tip", nil, nil, nil)
+	rsp, err := c.request(ctx, &res.Response, "GET", "/tip", nil, nil, nil, opts...)
 	if err != nil {
-		res.applyError(nil, err)
-		return
-	}
-
-	body, err := readResponseBody(rsp)
-	if err != nil {
-		res.applyError(body, err)
 		return
 	}
 
 	tips := []Tip{}
-	if err = json.Unmarshal(body, &tips); err != nil {
-		res.applyError(body, err)
+	if err = readAndUnmarshalResponse(rsp, &res.Response, &tips); err != nil {
 		return
 	}
 	if len(tips) == 1 {
 		res.Data = &tips[0]
 	}
-	res.ready()
 	return
 }
 
 // GetGenesis returns the Genesis parameters used to start specific era on chain.
-func (c *Client) GetGenesis(ctx context.Context) (res *GenesisResponse, err error) {
+func (c *Client) GetGenesis(ctx context.Context, opts ...CallOption) (res *GenesisResponse, err error) {
 	res = &GenesisResponse{}
-	rsp, err := c.request(ctx, &res.Response, "GET", "/genesis", nil, nil, nil)
-	if err != nil {
-		res.applyError(nil, err)
+
+	// genesis of the network never changes.
+	rc := c.responseCache(opts)
+	if rc != nil && rc.get(rc.key("genesis", ""), &res.Data) {
+		c.applyCached(ctx, rc, &res.Response, "GET", "genesis", nil)
 		return
 	}
 
-	body, err := readResponseBody(rsp)
+	rsp, err := c.request(ctx, &res.Response, "GET", "/genesis", nil, nil, nil, opts...)
 	if err != nil {
-		res.applyError(body, err)
 		return
 	}
 
 	genesisres := []Genesis{}
 
-	if err = json.Unmarshal(body, &genesisres); err != nil {
-		res.applyError(body, err)
+	if err = readAndUnmarshalResponse(rsp, &res.Response, &genesisres); err != nil {
 		return
 	}
 
 	if len(genesisres) == 1 {
 		res.Data = &genesisres[0]
+		if rc != nil {
+			rc.set(rc.key("genesis", ""), res.Data)
+		}
 	}
-	res.ready()
 	return
 }
 
 // GetTotals returns the circulating utxo, treasury, rewards, supply and
 // reserves in lovelace for specified epoch, all epochs if empty.
-func (c *Client) GetTotals(ctx context.Context, epoch *EpochNo) (res *TotalsResponse, err error) {
+func (c *Client) GetTotals(ctx context.Context, epoch *EpochNo, opts ...CallOption) (res *TotalsResponse, err error) {
 	params := url.Values{}
 	if epoch != nil {
 		params.Set("_epoch_no", fmt.Sprint(*epoch))
 	}
 	res = &TotalsResponse{}
-	rsp, err := c.request(ctx, &res.Response, "GET", "/totals", nil, params, nil)
-	if err != nil {
-		res.applyError(nil, err)
+
+	// totals of past epochs never change.
+	rc := c.responseCache(opts)
+	if rc != nil && epoch != nil &&
+		rc.get(rc.key("totals", fmt.Sprint(*epoch)), &res.Data) {
+		c.applyCached(ctx, rc, &res.Response, "GET", "totals", params)
 		return
 	}
 
-	body, err := readResponseBody(rsp)
+	rsp, err := c.request(ctx, &res.Response, "GET", "/totals", nil, params, nil, opts...)
 	if err != nil {
-		res.applyError(body, err)
 		return
 	}
 
 	totals := []Totals{}
-	if err = json.Unmarshal(body, &totals); err != nil {
-		res.applyError(body, err)
+	if err = readAndUnmarshalResponse(rsp, &res.Response, &totals); err != nil {
 		return
 	}
 	if len(totals) > 0 {
 		res.Data = totals
+		if rc != nil && epoch != nil {
+			if tip, _ := c.cachedTip(ctx, rc); rc.isPastEpoch(tip, *epoch) {
+				rc.set(rc.key("totals", fmt.Sprint(*epoch)), res.Data)
+			}
+		}
 	}
-	res.ready()
 	return
 }
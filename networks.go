@@ -0,0 +1,267 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Predefined network profiles.
+//
+// Mainnet: Cardano mainnet, default network of the API client.
+// Preprod: pre-production testnet.
+// Preview: preview testnet.
+// Guild  : Guild network with short epochs.
+// Testnet: legacy public testnet.
+//
+// Custom networks can be configured by passing own Network to UseNetwork.
+var (
+	Mainnet = Network{
+		Name:               "mainnet",
+		Host:               MainnetHost,
+		APIVersion:         DefaultAPIVersion,
+		Magic:              764824073,
+		AddressPrefix:      "addr",
+		StakeAddressPrefix: "stake",
+		Genesis: NetworkGenesis{
+			NetworkID:         1,
+			SystemStart:       time.Date(2017, 9, 23, 21, 44, 51, 0, time.UTC),
+			EpochLength:       432000,
+			SlotLength:        time.Second,
+			SecurityParam:     2160,
+			ActiveSlotsCoeff:  0.05,
+			MaxLovelaceSupply: 45000000000000000,
+		},
+	}
+	Preprod = Network{
+		Name:               "preprod",
+		Host:               PreprodHost,
+		APIVersion:         DefaultAPIVersion,
+		Magic:              1,
+		AddressPrefix:      "addr_test",
+		StakeAddressPrefix: "stake_test",
+		Genesis: NetworkGenesis{
+			SystemStart:       time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC),
+			EpochLength:       432000,
+			SlotLength:        time.Second,
+			SecurityParam:     2160,
+			ActiveSlotsCoeff:  0.05,
+			MaxLovelaceSupply: 45000000000000000,
+		},
+	}
+	Preview = Network{
+		Name:               "preview",
+		Host:               PreviewHost,
+		APIVersion:         DefaultAPIVersion,
+		Magic:              2,
+		AddressPrefix:      "addr_test",
+		StakeAddressPrefix: "stake_test",
+		Genesis: NetworkGenesis{
+			SystemStart:       time.Date(2022, 10, 25, 0, 0, 0, 0, time.UTC),
+			EpochLength:       86400,
+			SlotLength:        time.Second,
+			SecurityParam:     432,
+			ActiveSlotsCoeff:  0.05,
+			MaxLovelaceSupply: 45000000000000000,
+		},
+	}
+	// Guild network is respun from time to time, so its system start
+	// is not fixed, use GetGenesis to query it.
+	Guild = Network{
+		Name:               "guild",
+		Host:               GuildHost,
+		APIVersion:         DefaultAPIVersion,
+		Magic:              141,
+		AddressPrefix:      "addr_test",
+		StakeAddressPrefix: "stake_test",
+		Genesis: NetworkGenesis{
+			EpochLength:       3600,
+			SlotLength:        time.Second,
+			SecurityParam:     36,
+			ActiveSlotsCoeff:  0.05,
+			MaxLovelaceSupply: 45000000000000000,
+		},
+	}
+	Testnet = Network{
+		Name:               "testnet",
+		Host:               TestnetHost,
+		APIVersion:         DefaultAPIVersion,
+		Magic:              1097911063,
+		AddressPrefix:      "addr_test",
+		StakeAddressPrefix: "stake_test",
+		Genesis: NetworkGenesis{
+			SystemStart:       time.Date(2019, 7, 24, 20, 20, 16, 0, time.UTC),
+			EpochLength:       432000,
+			SlotLength:        time.Second,
+			SecurityParam:     2160,
+			ActiveSlotsCoeff:  0.05,
+			MaxLovelaceSupply: 45000000000000000,
+		},
+	}
+)
+
+type (
+	// Network is profile of Cardano network served by Koios instance.
+	Network struct {
+		// Name of the network e.g. mainnet, preprod.
+		Name string `json:"name"`
+
+		// Host of Koios instance serving the network.
+		Host string `json:"host"`
+
+		// APIVersion of Koios instance e.g. v0.
+		APIVersion string `json:"api_version"`
+
+		// Magic is network magic.
+		Magic uint32 `json:"magic"`
+
+		// AddressPrefix is bech32 prefix of payment addresses e.g. addr, addr_test.
+		AddressPrefix string `json:"address_prefix"`
+
+		// StakeAddressPrefix is bech32 prefix of stake addresses e.g. stake, stake_test.
+		StakeAddressPrefix string `json:"stake_address_prefix"`
+
+		// Genesis constants of the network.
+		Genesis NetworkGenesis `json:"genesis"`
+	}
+
+	// NetworkGenesis holds Shelley genesis constants of the network.
+	NetworkGenesis struct {
+		// NetworkID is 1 for mainnet and 0 for test networks.
+		NetworkID uint8 `json:"network_id"`
+
+		// SystemStart is time of the network start.
+		SystemStart time.Time `json:"system_start"`
+
+		// EpochLength is number of slots in epoch.
+		EpochLength uint64 `json:"epoch_length"`
+
+		// SlotLength is duration of single slot.
+		SlotLength time.Duration `json:"slot_length"`
+
+		// SecurityParam is number of blocks after which block is final.
+		SecurityParam uint64 `json:"security_param"`
+
+		// ActiveSlotsCoeff is active slots coefficient.
+		ActiveSlotsCoeff float64 `json:"active_slots_coeff"`
+
+		// MaxLovelaceSupply is maximum lovelace supply.
+		MaxLovelaceSupply uint64 `json:"max_lovelace_supply"`
+	}
+)
+
+// LookupNetwork returns predefined network profile by name
+// e.g. mainnet, preprod, preview, guild or testnet.
+func LookupNetwork(name string) (Network, bool) {
+	for _, n := range []Network{Mainnet, Preprod, Preview, Guild, Testnet} {
+		if strings.EqualFold(n.Name, name) {
+			return n, true
+		}
+	}
+	return Network{}, false
+}
+
+// UseNetwork returns option apply func which configures API client
+// for network n. It sets host and API version of the network and enables
+// validation of addresses passed to methods against network prefixes.
+// Validation is disabled again by Host or Instances option.
+// Use predefined profile e.g. koios.Preprod or own Network for custom networks.
+func UseNetwork(n Network) Option {
+	return func(c *Client) error {
+		if len(n.Host) == 0 {
+			return ErrNetworkHost
+		}
+		if len(n.APIVersion) == 0 {
+			n.APIVersion = DefaultAPIVersion
+		}
+		c.mux.Lock()
+		c.host = n.Host
+		c.version = n.APIVersion
+		c.network = &n
+		c.checkNetwork = true
+		c.mux.Unlock()
+		return c.updateBaseURL()
+	}
+}
+
+// Network returns network profile used by API client. Ok is false
+// when host was changed to custom host without using UseNetwork.
+func (c *Client) Network() (n Network, ok bool) {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	if c.network == nil {
+		return Network{}, false
+	}
+	return *c.network, true
+}
+
+// networkByHost returns predefined network served by host.
+func networkByHost(host string) *Network {
+	for _, n := range []Network{Mainnet, Preprod, Preview, Guild, Testnet} {
+		if n.Host == host {
+			return &n
+		}
+	}
+	return nil
+}
+
+// networkByInstances returns predefined network served by all instances,
+// nil is returned when instances serve different or custom networks.
+func networkByInstances(instances []*instance) *Network {
+	var n *Network
+	for _, inst := range instances {
+		in := networkByHost(inst.url.Hostname())
+		if in == nil || n != nil && n.Name != in.Name {
+			return nil
+		}
+		n = in
+	}
+	return n
+}
+
+// validateAddress checks that bech32 payment or stake address is well formed.
+// When network was selected with UseNetwork address must also belong to the
+// network, unless the call is sent to other host with WithHost. Addresses
+// which are not bech32 encoded e.g. Byron addresses are not validated.
+func (c *Client) validateAddress(addr string, opts []CallOption) error {
+	i := strings.LastIndexByte(addr, '1')
+	if i < 1 {
+		return nil
+	}
+	hrp := strings.ToLower(addr[:i])
+	if !strings.HasPrefix(hrp, "addr") && !strings.HasPrefix(hrp, "stake") {
+		return nil
+	}
+	if _, err := ParseAddress(addr); err != nil {
+		return err
+	}
+	c.mux.RLock()
+	n, check := c.network, c.checkNetwork
+	c.mux.RUnlock()
+	if !check || n == nil || len(n.AddressPrefix) == 0 && len(n.StakeAddressPrefix) == 0 {
+		return nil
+	}
+	if cfg := newCallConfig(opts); cfg.host != nil {
+		return nil
+	}
+	if hrp == n.AddressPrefix || hrp == n.StakeAddressPrefix {
+		return nil
+	}
+	return fmt.Errorf("%w: %s is not %s address", ErrNetworkMismatch, addr, n.Name)
+}
@@ -0,0 +1,154 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/howijd/koios-rest-go-client"
+)
+
+func TestUseNetwork(t *testing.T) {
+	api, err := koios.New(koios.UseNetwork(koios.Preprod))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://preprod.koios.rest/api/v0/", api.BaseURL())
+	n, ok := api.Network()
+	assert.True(t, ok)
+	assert.Equal(t, uint32(1), n.Magic)
+	assert.Equal(t, "addr_test", n.AddressPrefix)
+
+	// mainnet address is rejected before request is sent.
+	res, err := api.GetAddressInfo(context.Background(),
+		"addr1qxqs59lphg8g6qndelq8xwqn60ag3aeyfcp33c2kdp46a09re5df3pzwwmyq946axfcejy5n4x0y99wqpgtp2gd0k09qsgy6pz")
+	assert.True(t, errors.Is(err, koios.ErrNetworkMismatch), "expected network mismatch got: %v", err)
+	assert.NotNil(t, res.Error)
+	assert.Equal(t, uint64(0), api.TotalRequests())
+
+	_, err = koios.New(koios.UseNetwork(koios.Network{Name: "custom"}))
+	assert.ErrorIs(t, err, koios.ErrNetworkHost)
+}
+
+func TestNetworkByHost(t *testing.T) {
+	api, err := koios.New()
+	assert.NoError(t, err)
+	n, ok := api.Network()
+	assert.True(t, ok)
+	assert.Equal(t, koios.Mainnet.Name, n.Name)
+
+	assert.NoError(t, koios.Host(koios.PreviewHost)(api))
+	n, _ = api.Network()
+	assert.Equal(t, koios.Preview.Magic, n.Magic)
+
+	assert.NoError(t, koios.Host("localhost")(api))
+	_, ok = api.Network()
+	assert.False(t, ok, "custom host should not have network")
+
+	assert.NoError(t, koios.Instances(koios.RoundRobin,
+		koios.Instance{URL: "https://" + koios.PreprodHost + "/api/v0"},
+		koios.Instance{URL: "https://" + koios.PreprodHost + "/api/v1"},
+	)(api))
+	n, _ = api.Network()
+	assert.Equal(t, koios.Preprod.Name, n.Name)
+
+	assert.NoError(t, koios.Instances(koios.RoundRobin,
+		koios.Instance{URL: "https://" + koios.PreprodHost + "/api/v0"},
+		koios.Instance{URL: "https://" + koios.PreviewHost + "/api/v0"},
+	)(api))
+	_, ok = api.Network()
+	assert.False(t, ok, "instances of different networks should not have network")
+
+	n, ok = koios.LookupNetwork("Guild")
+	assert.True(t, ok)
+	assert.Equal(t, koios.GuildHost, n.Host)
+}
+
+func TestNetworkValidation(t *testing.T) {
+	const (
+		testAddr = koios.Address("addr_test1qz2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer3n0d3vll" +
+			"myqwsx5wktcd8cc3sq835lu7drv2xwl2wywfgs68faae")
+		testStake = koios.StakeAddress("stake_test1uqehkck0lajq8gr28t9uxnuvgcqrc6070x3k9r8048z8y5gssrtvn")
+	)
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("[]"))
+	}))
+	t.Cleanup(ts.Close)
+	// all hosts are served by test server.
+	client := &http.Client{Timeout: 10 * time.Second, Transport: rewriteTransport{ts.URL}}
+	ctx := context.Background()
+
+	// default client checks only that address is well formed.
+	api, err := koios.New(koios.HTTPClient(client), koios.RateLimit(255))
+	assert.NoError(t, err)
+	_, err = api.GetAddressInfo(ctx, testAddr)
+	assert.NoError(t, err)
+	_, err = api.GetAccountInfo(ctx, koios.Address(testStake))
+	assert.NoError(t, err)
+	_, err = api.GetAddressInfo(ctx, testAddr[:len(testAddr)-1]+"x")
+	assert.ErrorIs(t, err, koios.ErrInvalidAddress)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+
+	// network selected with UseNetwork is enforced.
+	api, err = koios.New(koios.HTTPClient(client), koios.RateLimit(255), koios.UseNetwork(koios.Mainnet))
+	assert.NoError(t, err)
+	_, err = api.GetAccountRewards(ctx, testStake, nil)
+	assert.ErrorIs(t, err, koios.ErrNetworkMismatch)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+
+	// unless call is sent to other host.
+	_, err = api.GetAccountRewards(ctx, testStake, nil, koios.WithHost("https://"+koios.PreprodHost+"/api/v0"))
+	assert.NoError(t, err)
+	_, err = api.GetAddressTxs(ctx, []koios.Address{testAddr}, 0, koios.WithHost("https://"+koios.PreviewHost+"/api/v0"))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&hits))
+
+	// or instances replaced the network.
+	assert.NoError(t, koios.Instances(koios.RoundRobin,
+		koios.Instance{URL: "https://" + koios.PreprodHost + "/api/v0"},
+	)(api))
+	_, err = api.GetAccountInfo(ctx, koios.Address(testStake))
+	assert.NoError(t, err)
+	_, err = api.GetAddressInfo(ctx, testAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(6), atomic.LoadInt32(&hits))
+}
+
+// rewriteTransport sends all requests to test server at url.
+type rewriteTransport struct {
+	url string
+}
+
+func (rt rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	u, err := url.Parse(rt.url)
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = u.Scheme, u.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
@@ -0,0 +1,197 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package otelkoios provides OpenTelemetry instrumentation
+// for Koios API client.
+//
+// e.g.
+//
+//	telemetry, err := otelkoios.New()
+//	if err != nil {
+//		...
+//	}
+//	api, err := koios.New(koios.Instrumentation(telemetry))
+package otelkoios
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/howijd/koios-rest-go-client"
+)
+
+// InstrumentationName is name of the instrumentation library.
+const InstrumentationName = "github.com/howijd/koios-rest-go-client/otelkoios"
+
+type (
+	// Telemetry implements koios.Telemetry creating span per API call
+	// and recording call counters and latency histogram per endpoint.
+	Telemetry struct {
+		tracer   trace.Tracer
+		calls    metric.Int64Counter
+		errors   metric.Int64Counter
+		retries  metric.Int64Counter
+		sent     metric.Int64Counter
+		received metric.Int64Counter
+		duration metric.Float64Histogram
+	}
+
+	// Option configures Telemetry.
+	Option func(*config)
+
+	config struct {
+		tp trace.TracerProvider
+		mp metric.MeterProvider
+	}
+
+	callSpan struct {
+		t    *Telemetry
+		ctx  context.Context
+		span trace.Span
+	}
+)
+
+// WithTracerProvider sets tracer provider used to create spans,
+// global tracer provider is used by default.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		if tp != nil {
+			c.tp = tp
+		}
+	}
+}
+
+// WithMeterProvider sets meter provider used to create metric instruments,
+// global meter provider is used by default.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(c *config) {
+		if mp != nil {
+			c.mp = mp
+		}
+	}
+}
+
+// New returns OpenTelemetry instrumentation for koios.Instrumentation option.
+func New(opts ...Option) (*Telemetry, error) {
+	cfg := config{
+		tp: otel.GetTracerProvider(),
+		mp: otel.GetMeterProvider(),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	meter := cfg.mp.Meter(InstrumentationName, metric.WithInstrumentationVersion(koios.LibraryVersion))
+	t := &Telemetry{
+		tracer: cfg.tp.Tracer(InstrumentationName, trace.WithInstrumentationVersion(koios.LibraryVersion)),
+	}
+
+	var err error
+	if t.calls, err = meter.Int64Counter("koios.client.calls",
+		metric.WithDescription("Number of Koios API calls."),
+		metric.WithUnit("{call}"),
+	); err != nil {
+		return nil, err
+	}
+	if t.errors, err = meter.Int64Counter("koios.client.errors",
+		metric.WithDescription("Number of failed Koios API calls."),
+		metric.WithUnit("{call}"),
+	); err != nil {
+		return nil, err
+	}
+	if t.retries, err = meter.Int64Counter("koios.client.retries",
+		metric.WithDescription("Number of retried Koios API requests."),
+		metric.WithUnit("{request}"),
+	); err != nil {
+		return nil, err
+	}
+	if t.sent, err = meter.Int64Counter("koios.client.sent",
+		metric.WithDescription("Size of Koios API request payloads."),
+		metric.WithUnit("By"),
+	); err != nil {
+		return nil, err
+	}
+	if t.received, err = meter.Int64Counter("koios.client.received",
+		metric.WithDescription("Size of Koios API response bodies."),
+		metric.WithUnit("By"),
+	); err != nil {
+		return nil, err
+	}
+	if t.duration, err = meter.Float64Histogram("koios.client.duration",
+		metric.WithDescription("Duration of Koios API calls."),
+		metric.WithUnit("s"),
+	); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// StartCall implements koios.Telemetry interface.
+func (t *Telemetry) StartCall(ctx context.Context, endpoint, method string) (context.Context, koios.CallSpan) {
+	ctx, span := t.tracer.Start(ctx, "koios "+endpoint,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("koios.endpoint", endpoint),
+			attribute.String("http.request.method", method),
+		),
+	)
+	return ctx, &callSpan{t: t, ctx: ctx, span: span}
+}
+
+// End implements koios.CallSpan interface.
+func (s *callSpan) End(info koios.CallInfo) {
+	s.span.SetAttributes(
+		attribute.String("url.full", info.URL),
+		attribute.Int("koios.attempts", info.Attempts),
+		attribute.Int64("koios.bytes_sent", info.BytesSent),
+		attribute.Int64("koios.bytes_received", info.BytesReceived),
+		attribute.Bool("koios.cached", info.Cached),
+	)
+	if info.StatusCode != 0 {
+		s.span.SetAttributes(attribute.Int("http.response.status_code", info.StatusCode))
+	}
+	switch {
+	case info.Err != nil:
+		s.span.RecordError(info.Err)
+		s.span.SetStatus(codes.Error, info.Err.Error())
+	case info.StatusCode >= 400:
+		s.span.SetStatus(codes.Error, http.StatusText(info.StatusCode))
+	}
+	s.span.End()
+
+	attrs := metric.WithAttributes(
+		attribute.String("koios.endpoint", info.Endpoint),
+		attribute.String("http.request.method", info.Method),
+		attribute.Int("http.response.status_code", info.StatusCode),
+		attribute.Bool("koios.cached", info.Cached),
+	)
+	s.t.calls.Add(s.ctx, 1, attrs)
+	if info.Err != nil || info.StatusCode >= 400 {
+		s.t.errors.Add(s.ctx, 1, attrs)
+	}
+	if info.Attempts > 1 {
+		s.t.retries.Add(s.ctx, int64(info.Attempts-1), attrs)
+	}
+	s.t.sent.Add(s.ctx, info.BytesSent, attrs)
+	s.t.received.Add(s.ctx, info.BytesReceived, attrs)
+	s.t.duration.Record(s.ctx, info.Duration.Seconds(), attrs)
+}
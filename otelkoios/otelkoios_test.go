@@ -0,0 +1,170 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otelkoios_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/howijd/koios-rest-go-client"
+	"github.com/howijd/koios-rest-go-client/koiostest"
+	"github.com/howijd/koios-rest-go-client/otelkoios"
+)
+
+func TestTelemetry(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	telemetry, err := otelkoios.New(
+		otelkoios.WithTracerProvider(tp),
+		otelkoios.WithMeterProvider(mp),
+	)
+	assert.NoError(t, err)
+
+	srv := koiostest.NewServer()
+	defer srv.Close()
+	srv.InjectFault("tip", koiostest.Fault{StatusCode: http.StatusNotFound, Times: 1})
+	api, err := srv.Client(koios.Instrumentation(telemetry))
+	assert.NoError(t, err)
+
+	_, err = api.GetTip(context.Background())
+	assert.ErrorIs(t, err, koios.ErrNotFound)
+	_, err = api.GetTip(context.Background())
+	assert.NoError(t, err)
+
+	ended := spans.Ended()
+	if assert.Len(t, ended, 2) {
+		for _, span := range ended {
+			assert.Equal(t, "koios tip", span.Name())
+			assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+			assert.Equal(t, otelkoios.InstrumentationName, span.InstrumentationScope().Name)
+			assert.Contains(t, span.Attributes(), attribute.String("koios.endpoint", "tip"))
+			assert.Contains(t, span.Attributes(), attribute.String("http.request.method", http.MethodGet))
+		}
+		assert.Equal(t, codes.Error, ended[0].Status().Code)
+		assert.Contains(t, ended[0].Attributes(), attribute.Int("http.response.status_code", http.StatusNotFound))
+		assert.Equal(t, codes.Unset, ended[1].Status().Code)
+		assert.Contains(t, ended[1].Attributes(), attribute.Int("http.response.status_code", http.StatusOK))
+	}
+
+	var rm metricdata.ResourceMetrics
+	assert.NoError(t, reader.Collect(context.Background(), &rm))
+	if !assert.Len(t, rm.ScopeMetrics, 1) {
+		return
+	}
+	assert.Equal(t, otelkoios.InstrumentationName, rm.ScopeMetrics[0].Scope.Name)
+	metrics := map[string]metricdata.Aggregation{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m.Data
+	}
+
+	calls, ok := metrics["koios.client.calls"].(metricdata.Sum[int64])
+	if assert.True(t, ok) {
+		assert.Equal(t, int64(2), sumInt64(calls))
+	}
+	failed, ok := metrics["koios.client.errors"].(metricdata.Sum[int64])
+	if assert.True(t, ok) && assert.Len(t, failed.DataPoints, 1) {
+		status, _ := failed.DataPoints[0].Attributes.Value("http.response.status_code")
+		assert.Equal(t, int64(http.StatusNotFound), status.AsInt64())
+		assert.Equal(t, int64(1), failed.DataPoints[0].Value)
+	}
+	received, ok := metrics["koios.client.received"].(metricdata.Sum[int64])
+	if assert.True(t, ok) {
+		assert.Greater(t, sumInt64(received), int64(0))
+	}
+	duration, ok := metrics["koios.client.duration"].(metricdata.Histogram[float64])
+	if assert.True(t, ok) {
+		var count uint64
+		for _, dp := range duration.DataPoints {
+			count += dp.Count
+		}
+		assert.Equal(t, uint64(2), count)
+	}
+}
+
+func sumInt64(sum metricdata.Sum[int64]) (total int64) {
+	for _, dp := range sum.DataPoints {
+		total += dp.Value
+	}
+	return total
+}
+
+func TestTelemetryCached(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	telemetry, err := otelkoios.New(
+		otelkoios.WithTracerProvider(tp),
+		otelkoios.WithMeterProvider(mp),
+	)
+	assert.NoError(t, err)
+
+	srv := koiostest.NewServer()
+	defer srv.Close()
+	api, err := srv.Client(
+		koios.Instrumentation(telemetry),
+		koios.ResponseCache(koios.NewLRUCache(10), koios.DefaultCachePolicy()),
+	)
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		res, err := api.GetGenesis(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, i == 1, res.Cached)
+	}
+	assert.Equal(t, 1, srv.Requests("genesis"))
+
+	ended := spans.Ended()
+	if assert.Len(t, ended, 2) {
+		assert.Equal(t, "koios genesis", ended[1].Name())
+		assert.Contains(t, ended[0].Attributes(), attribute.Bool("koios.cached", false))
+		assert.Contains(t, ended[1].Attributes(), attribute.Bool("koios.cached", true))
+		assert.Contains(t, ended[1].Attributes(), attribute.Int("http.response.status_code", http.StatusOK))
+	}
+
+	var rm metricdata.ResourceMetrics
+	assert.NoError(t, reader.Collect(context.Background(), &rm))
+	if !assert.Len(t, rm.ScopeMetrics, 1) {
+		return
+	}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		if m.Name != "koios.client.calls" {
+			continue
+		}
+		calls, ok := m.Data.(metricdata.Sum[int64])
+		if assert.True(t, ok) && assert.Len(t, calls.DataPoints, 2) {
+			for _, dp := range calls.DataPoints {
+				cached, _ := dp.Attributes.Value("koios.cached")
+				assert.Equal(t, int64(1), dp.Value, "cached: %t", cached.AsBool())
+			}
+		}
+	}
+}
@@ -0,0 +1,260 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type (
+	// pageFetcher fetches single page of paginated endpoint
+	// limited with offset and limit provided in query.
+	pageFetcher[T any] func(ctx context.Context, query url.Values) ([]T, *Response, error)
+
+	// PageInfo is parsed Content-Range response header
+	// e.g. 0-999/* or 0-999/48312. Unknown values are set to -1.
+	// Total is known only when exact count was requested
+	// e.g. with WithExactCount or ExactCount options.
+	PageInfo struct {
+		// First is zero based index of the first row of the response.
+		First int64 `json:"first"`
+
+		// Last is zero based index of the last row of the response.
+		Last int64 `json:"last"`
+
+		// Total count of rows matching the request.
+		Total int64 `json:"total"`
+	}
+
+	// Iterator walks all items of paginated endpoint by requesting
+	// next page from the server when items of current page are exhausted.
+	//
+	// e.g.
+	// pools := api.PoolListIterator(ctx)
+	// for pools.Next() {
+	// 	pool := pools.Value()
+	// }
+	// if err := pools.Err(); err != nil {
+	// 	...
+	// }
+	Iterator[T any] struct {
+		ctx      context.Context
+		fetch    pageFetcher[T]
+		pageSize uint
+		offset   uint
+		total    int64
+		page     []T
+		idx      int
+		done     bool
+		err      error
+		res      *Response
+	}
+)
+
+// ExactCount returns option apply func which makes API client request
+// exact count of rows with "Prefer: count=exact" header for all GET requests,
+// so Response.PageInfo and Iterator.Total report total count of rows.
+// Counting is expensive for large tables, use WithExactCount to request
+// exact count only for selected calls.
+func ExactCount(enabled bool) Option {
+	return func(c *Client) error {
+		c.mux.Lock()
+		defer c.mux.Unlock()
+		c.exactCount = enabled
+		return nil
+	}
+}
+
+// PageSize sets number of rows requested per page by iterators and streams,
+// DefaultPageSize by default. Page size larger than max rows of the instance
+// (e.g. 500 on some community instances) is capped by the server,
+// pagination continues until empty page or reported total is reached.
+func PageSize(size uint) Option {
+	return func(c *Client) error {
+		if size == 0 {
+			return ErrPageSize
+		}
+		c.mux.Lock()
+		defer c.mux.Unlock()
+		c.pageSize = size
+		return nil
+	}
+}
+
+// newIterator returns iterator for paginated endpoint.
+func newIterator[T any](ctx context.Context, c *Client, fetch pageFetcher[T]) *Iterator[T] {
+	c.mux.RLock()
+	pageSize := c.pageSize
+	c.mux.RUnlock()
+	return &Iterator[T]{
+		ctx:      ctx,
+		fetch:    fetch,
+		pageSize: pageSize,
+		total:    -1,
+		idx:      -1,
+	}
+}
+
+// Next advances the iterator to the next item, fetching next page
+// when needed. It returns false when all items have been consumed
+// or when error occurred in which case Err returns that error.
+func (it *Iterator[T]) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.idx+1 < len(it.page) {
+		it.idx++
+		return true
+	}
+	if it.done {
+		return false
+	}
+	if err := it.nextPage(); err != nil {
+		it.err = err
+		return false
+	}
+	if len(it.page) == 0 {
+		return false
+	}
+	it.idx = 0
+	return true
+}
+
+// Value returns current item. It must be called only after
+// call to Next returned true.
+func (it *Iterator[T]) Value() T {
+	return it.page[it.idx]
+}
+
+// Err returns first error occurred while fetching the pages.
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// Total returns total count of the items when server reported it
+// through Content-Range header. Second return value is false when
+// total is unknown.
+func (it *Iterator[T]) Total() (int64, bool) {
+	return it.total, it.total >= 0
+}
+
+// Response returns response of the last fetched page.
+func (it *Iterator[T]) Response() *Response {
+	return it.res
+}
+
+func (it *Iterator[T]) nextPage() error {
+	query := url.Values{}
+	query.Set("offset", fmt.Sprint(it.offset))
+	query.Set("limit", fmt.Sprint(it.pageSize))
+
+	page, res, err := it.fetch(it.ctx, query)
+	it.res = res
+	if err != nil {
+		return err
+	}
+
+	it.page = page
+	it.offset += uint(len(page))
+
+	if res != nil {
+		if res.PageInfo != nil && res.PageInfo.Total >= 0 {
+			it.total = res.PageInfo.Total
+		}
+	}
+
+	if isLastPage(len(page), it.offset, it.total) {
+		it.done = true
+	}
+	return nil
+}
+
+// isLastPage reports whether page of n rows ending at offset is the last one.
+// Short page is not the last one since server may cap the limit to its
+// max rows, so page is last when it is empty or reported total is reached.
+func isLastPage(n int, offset uint, total int64) bool {
+	return n == 0 || total >= 0 && int64(offset) >= total
+}
+
+// Rows returns number of rows in the response.
+func (p PageInfo) Rows() int64 {
+	if p.First < 0 || p.Last < p.First {
+		return 0
+	}
+	return p.Last - p.First + 1
+}
+
+// String returns human readable page info e.g. "1-1000 of 48312",
+// "1-1000" when total is unknown or "0 of 48312" for empty page.
+func (p PageInfo) String() string {
+	var rng string
+	if p.Rows() > 0 {
+		rng = fmt.Sprintf("%d-%d", p.First+1, p.Last+1)
+	} else {
+		rng = "0"
+	}
+	if p.Total < 0 {
+		return rng
+	}
+	return fmt.Sprintf("%s of %d", rng, p.Total)
+}
+
+// parseContentRange parses Content-Range header returned by PostgREST.
+// e.g. "0-999/*", "0-999/48312", "*/0".
+func parseContentRange(header string) (cr PageInfo, ok bool) {
+	cr = PageInfo{First: -1, Last: -1, Total: -1}
+	header = strings.TrimSpace(header)
+	if len(header) == 0 {
+		return cr, false
+	}
+	// strip optional unit e.g. "items 0-24/*"
+	if i := strings.IndexByte(header, ' '); i != -1 {
+		header = header[i+1:]
+	}
+	rng, total, found := strings.Cut(header, "/")
+	if !found {
+		return cr, false
+	}
+	if total != "*" {
+		t, err := strconv.ParseInt(total, 10, 64)
+		if err != nil {
+			return cr, false
+		}
+		cr.Total = t
+	}
+	if rng == "*" {
+		return cr, true
+	}
+	first, last, found := strings.Cut(rng, "-")
+	if !found {
+		return cr, false
+	}
+	f, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return cr, false
+	}
+	l, err := strconv.ParseInt(last, 10, 64)
+	if err != nil {
+		return cr, false
+	}
+	cr.First, cr.Last = f, l
+	return cr, true
+}
@@ -0,0 +1,198 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/howijd/koios-rest-go-client"
+)
+
+func newTestClient(t *testing.T, handler http.Handler, opts ...koios.Option) *koios.Client {
+	t.Helper()
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+
+	u, err := url.Parse(ts.URL)
+	assert.NoError(t, err)
+	port, err := strconv.ParseUint(u.Port(), 10, 16)
+	assert.NoError(t, err)
+
+	api, err := koios.New(append([]koios.Option{
+		koios.Schema(u.Scheme),
+		koios.Host(u.Hostname()),
+		koios.Port(uint16(port)),
+		koios.RateLimit(255),
+	}, opts...)...)
+	assert.NoError(t, err)
+	return api
+}
+
+func TestPoolListIterator(t *testing.T) {
+	const total = 2500
+	api := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		last := offset + limit
+		if last > total {
+			last = total
+		}
+		var items []koios.PoolListItem
+		for i := offset; i < last; i++ {
+			items = append(items, koios.PoolListItem{PoolID: koios.PoolID(fmt.Sprint("pool", i))})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Range", fmt.Sprintf("%d-%d/%d", offset, last-1, total))
+		_ = json.NewEncoder(w).Encode(items)
+	}))
+
+	pools := api.PoolListIterator(context.Background())
+	count := 0
+	for pools.Next() {
+		assert.Equal(t, koios.PoolID(fmt.Sprint("pool", count)), pools.Value().PoolID)
+		count++
+	}
+	assert.NoError(t, pools.Err())
+	assert.Equal(t, total, count)
+	assert.Equal(t, uint64(3), api.TotalRequests(), "expected 3 page requests")
+
+	n, ok := pools.Total()
+	assert.True(t, ok, "total should be known")
+	assert.Equal(t, int64(total), n)
+}
+
+func TestIteratorMaxRows(t *testing.T) {
+	const (
+		total   = 1200
+		maxRows = 500
+	)
+	var limits []string
+	handler := func(exact bool) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limits = append(limits, r.URL.Query().Get("limit"))
+			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+			last := offset + maxRows
+			if last > total {
+				last = total
+			}
+			var items []koios.PoolListItem
+			for i := offset; i < last; i++ {
+				items = append(items, koios.PoolListItem{PoolID: koios.PoolID(fmt.Sprint("pool", i))})
+			}
+			w.Header().Set("Content-Type", "application/json")
+			if exact {
+				w.Header().Set("Content-Range", fmt.Sprintf("%d-%d/%d", offset, last-1, total))
+			} else {
+				w.Header().Set("Content-Range", fmt.Sprintf("%d-%d/*", offset, last-1))
+			}
+			_ = json.NewEncoder(w).Encode(items)
+		})
+	}
+
+	// server caps page to its max rows, short page is not the last one.
+	api := newTestClient(t, handler(false))
+	pools := api.PoolListIterator(context.Background())
+	count := 0
+	for pools.Next() {
+		assert.Equal(t, koios.PoolID(fmt.Sprint("pool", count)), pools.Value().PoolID)
+		count++
+	}
+	assert.NoError(t, pools.Err())
+	assert.Equal(t, total, count)
+	assert.Equal(t, uint64(4), api.TotalRequests(), "expected 3 pages and empty page")
+
+	// known total ends iteration without empty page.
+	limits = nil
+	api = newTestClient(t, handler(true), koios.PageSize(maxRows))
+	pools = api.PoolListIterator(context.Background())
+	count = 0
+	for pools.Next() {
+		count++
+	}
+	assert.NoError(t, pools.Err())
+	assert.Equal(t, total, count)
+	assert.Equal(t, []string{"500", "500", "500"}, limits)
+
+	_, err := koios.New(koios.PageSize(0))
+	assert.ErrorIs(t, err, koios.ErrPageSize)
+}
+
+func TestIteratorUnknownTotal(t *testing.T) {
+	api := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		var items []koios.TxMetalabel
+		if offset == 0 {
+			items = []koios.TxMetalabel{{Metalabel: 1}, {Metalabel: 721}}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Range", "0-1/*")
+		_ = json.NewEncoder(w).Encode(items)
+	}))
+
+	labels := api.TxMetaLabelsIterator(context.Background())
+	var got []uint64
+	for labels.Next() {
+		got = append(got, labels.Value().Metalabel)
+	}
+	assert.NoError(t, labels.Err())
+	assert.Equal(t, []uint64{1, 721}, got)
+	_, ok := labels.Total()
+	assert.False(t, ok, "total should be unknown")
+}
+
+func TestPageInfo(t *testing.T) {
+	var prefer string
+	api := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefer = r.Header.Get("Prefer")
+		w.Header().Set("Content-Type", "application/json")
+		if len(prefer) > 0 {
+			w.Header().Set("Content-Range", "0-999/48312")
+		} else {
+			w.Header().Set("Content-Range", "0-999/*")
+		}
+		_, _ = w.Write([]byte("[]"))
+	}), koios.ExactCount(true))
+
+	res, err := api.GetAssetAddressList(context.Background(), "policy", "name")
+	assert.NoError(t, err)
+	assert.Equal(t, "count=exact", prefer)
+	if assert.NotNil(t, res.PageInfo) {
+		assert.Equal(t, koios.PageInfo{First: 0, Last: 999, Total: 48312}, *res.PageInfo)
+		assert.Equal(t, int64(1000), res.PageInfo.Rows())
+		assert.Equal(t, "1-1000 of 48312", res.PageInfo.String())
+	}
+
+	assert.NoError(t, koios.ExactCount(false)(api))
+	res, err = api.GetAssetAddressList(context.Background(), "policy", "name")
+	assert.NoError(t, err)
+	assert.Empty(t, prefer)
+	if assert.NotNil(t, res.PageInfo) {
+		assert.Equal(t, int64(-1), res.PageInfo.Total)
+		assert.Equal(t, "1-1000", res.PageInfo.String())
+	}
+
+	assert.Equal(t, "0 of 0", koios.PageInfo{First: -1, Last: -1, Total: 0}.String())
+}
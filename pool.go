@@ -21,7 +21,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
 	"net/url"
 )
 
@@ -313,36 +312,39 @@ type (
 )
 
 // GetPoolList returns the list of all currently registered/retiring (not retired) pools.
-func (c *Client) GetPoolList(ctx context.Context) (res *PoolListResponse, err error) {
-	res = &PoolListResponse{}
-	rsp, err := c.request(ctx, &res.Response, "GET", "/pool_list", nil, nil, nil)
-	if err != nil {
-		res.applyError(nil, err)
-		return
-	}
+// Use PoolListIterator to walk through all pages.
+func (c *Client) GetPoolList(ctx context.Context, opts ...CallOption) (res *PoolListResponse, err error) {
+	return c.getPoolList(ctx, nil, opts...)
+}
 
-	body, err := readResponseBody(rsp)
-	if err != nil {
-		res.applyError(body, err)
-		return
-	}
+// PoolListIterator returns iterator over all currently
+// registered/retiring (not retired) pools.
+func (c *Client) PoolListIterator(ctx context.Context, opts ...CallOption) *Iterator[PoolListItem] {
+	return newIterator(ctx, c, func(ctx context.Context, query url.Values) ([]PoolListItem, *Response, error) {
+		res, err := c.getPoolList(ctx, query, opts...)
+		return res.Data, &res.Response, err
+	})
+}
 
-	if err = json.Unmarshal(body, &res.Data); err != nil {
-		res.applyError(body, err)
+func (c *Client) getPoolList(
+	ctx context.Context,
+	query url.Values,
+	opts ...CallOption,
+) (res *PoolListResponse, err error) {
+	res = &PoolListResponse{}
+	rsp, err := c.request(ctx, &res.Response, "GET", "/pool_list", nil, query, nil, opts...)
+	if err != nil {
 		return
 	}
 
-	if rsp.StatusCode != http.StatusOK {
-		res.applyError(body, err)
-		return
-	}
-	return res, nil
+	err = readAndUnmarshalResponse(rsp, &res.Response, &res.Data)
+	return
 }
 
 // GetPoolInfo returns current pool status and details for a specified pool.
-func (c *Client) GetPoolInfo(ctx context.Context, pid PoolID) (res *PoolInfoResponse, err error) {
+func (c *Client) GetPoolInfo(ctx context.Context, pid PoolID, opts ...CallOption) (res *PoolInfoResponse, err error) {
 	res = &PoolInfoResponse{}
-	rsp, err := c.GetPoolInfos(ctx, []PoolID{pid})
+	rsp, err := c.GetPoolInfos(ctx, []PoolID{pid}, opts...)
 	res.Response = rsp.Response
 	if len(rsp.Data) == 1 {
 		res.Data = &rsp.Data[0]
@@ -352,31 +354,22 @@ func (c *Client) GetPoolInfo(ctx context.Context, pid PoolID) (res *PoolInfoResp
 
 // GetTxsInfos returns current pool statuses and details
 // for a specified list of pool ids.
-func (c *Client) GetPoolInfos(ctx context.Context, pids []PoolID) (res *PoolInfosResponse, err error) {
+func (c *Client) GetPoolInfos(
+	ctx context.Context,
+	pids []PoolID,
+	opts ...CallOption,
+) (res *PoolInfosResponse, err error) {
 	res = &PoolInfosResponse{}
 	if len(pids) == 0 {
-		err = ErrNoPoolID
-		res.applyError(nil, err)
+		err = res.applyError(nil, ErrNoPoolID)
 		return
 	}
 
-	rsp, err := c.request(ctx, &res.Response, "POST", "/pool_info", poolIdsPL(pids), nil, nil)
-	if err != nil {
-		res.applyError(nil, err)
-		return
-	}
-
-	body, err := readResponseBody(rsp)
-	if err != nil {
-		res.applyError(body, err)
-		return
-	}
-	if err = json.Unmarshal(body, &res.Data); err != nil {
-		res.applyError(body, err)
-		return
-	}
-	res.ready()
-	return res, nil
+	res.Data, err = bulk(ctx, c, &res.Response, pids,
+		func(v PoolInfo) PoolID { return v.ID },
+		postChunk[PoolID, PoolInfo](c, "/pool_info", poolIdsPL, opts),
+	)
+	return
 }
 
 // GetPoolDelegators returns information about delegators
@@ -385,6 +378,7 @@ func (c *Client) GetPoolDelegators(
 	ctx context.Context,
 	pid PoolID,
 	epoch *EpochNo,
+	opts ...CallOption,
 ) (res *PoolDelegatorsResponse, err error) {
 	res = &PoolDelegatorsResponse{}
 
@@ -393,23 +387,13 @@ func (c *Client) GetPoolDelegators(
 	if epoch != nil {
 		params.Set("_epoch_no", fmt.Sprint(*epoch))
 	}
-	rsp, err := c.request(ctx, &res.Response, "GET", "/pool_delegators", nil, params, nil)
+	rsp, err := c.request(ctx, &res.Response, "GET", "/pool_delegators", nil, params, nil, opts...)
 	if err != nil {
-		res.applyError(nil, err)
 		return
 	}
 
-	body, err := readResponseBody(rsp)
-	if err != nil {
-		res.applyError(body, err)
-		return
-	}
-	if err = json.Unmarshal(body, &res.Data); err != nil {
-		res.applyError(body, err)
-		return
-	}
-	res.ready()
-	return res, nil
+	err = readAndUnmarshalResponse(rsp, &res.Response, &res.Data)
+	return
 }
 
 // GetPoolBlocks returns information about blocks minted by a given pool
@@ -418,6 +402,7 @@ func (c *Client) GetPoolBlocks(
 	ctx context.Context,
 	pid PoolID,
 	epoch *EpochNo,
+	opts ...CallOption,
 ) (res *PoolBlocksResponse, err error) {
 	res = &PoolBlocksResponse{}
 
@@ -426,23 +411,13 @@ func (c *Client) GetPoolBlocks(
 	if epoch != nil {
 		params.Set("_epoch_no", fmt.Sprint(*epoch))
 	}
-	rsp, err := c.request(ctx, &res.Response, "GET", "/pool_blocks", nil, params, nil)
+	rsp, err := c.request(ctx, &res.Response, "GET", "/pool_blocks", nil, params, nil, opts...)
 	if err != nil {
-		res.applyError(nil, err)
 		return
 	}
 
-	body, err := readResponseBody(rsp)
-	if err != nil {
-		res.applyError(body, err)
-		return
-	}
-	if err = json.Unmarshal(body, &res.Data); err != nil {
-		res.applyError(body, err)
-		return
-	}
-	res.ready()
-	return res, nil
+	err = readAndUnmarshalResponse(rsp, &res.Response, &res.Data)
+	return
 }
 
 // GetPoolUpdates returns all pool updates for all pools or
@@ -450,6 +425,7 @@ func (c *Client) GetPoolBlocks(
 func (c *Client) GetPoolUpdates(
 	ctx context.Context,
 	pid *PoolID,
+	opts ...CallOption,
 ) (res *PoolUpdatesResponse, err error) {
 	res = &PoolUpdatesResponse{}
 
@@ -458,71 +434,41 @@ func (c *Client) GetPoolUpdates(
 		params.Set("_pool_bech32", fmt.Sprint(*pid))
 	}
 
-	rsp, err := c.request(ctx, &res.Response, "GET", "/pool_updates", nil, params, nil)
+	rsp, err := c.request(ctx, &res.Response, "GET", "/pool_updates", nil, params, nil, opts...)
 	if err != nil {
-		res.applyError(nil, err)
 		return
 	}
 
-	body, err := readResponseBody(rsp)
-	if err != nil {
-		res.applyError(body, err)
-		return
-	}
-	if err = json.Unmarshal(body, &res.Data); err != nil {
-		res.applyError(body, err)
-		return
-	}
-	res.ready()
-	return res, nil
+	err = readAndUnmarshalResponse(rsp, &res.Response, &res.Data)
+	return
 }
 
 // GetPoolRelays returns a list of registered relays
 // for all currently registered/retiring (not retired) pools.
-func (c *Client) GetPoolRelays(ctx context.Context) (res *PoolRelaysResponse, err error) {
+func (c *Client) GetPoolRelays(ctx context.Context, opts ...CallOption) (res *PoolRelaysResponse, err error) {
 	res = &PoolRelaysResponse{}
 
-	rsp, err := c.request(ctx, &res.Response, "GET", "/pool_relays", nil, nil, nil)
+	rsp, err := c.request(ctx, &res.Response, "GET", "/pool_relays", nil, nil, nil, opts...)
 	if err != nil {
-		res.applyError(nil, err)
 		return
 	}
 
-	body, err := readResponseBody(rsp)
-	if err != nil {
-		res.applyError(body, err)
-		return
-	}
-	if err = json.Unmarshal(body, &res.Data); err != nil {
-		res.applyError(body, err)
-		return
-	}
-	res.ready()
-	return res, nil
+	err = readAndUnmarshalResponse(rsp, &res.Response, &res.Data)
+	return
 }
 
 // GetPoolMetadata returns Metadata(on & off-chain)
 // for all currently registered/retiring (not retired) pools.
-func (c *Client) GetPoolMetadata(ctx context.Context) (res *PoolMetadataResponse, err error) {
+func (c *Client) GetPoolMetadata(ctx context.Context, opts ...CallOption) (res *PoolMetadataResponse, err error) {
 	res = &PoolMetadataResponse{}
 
-	rsp, err := c.request(ctx, &res.Response, "GET", "/pool_metadata", nil, nil, nil)
+	rsp, err := c.request(ctx, &res.Response, "GET", "/pool_metadata", nil, nil, nil, opts...)
 	if err != nil {
-		res.applyError(nil, err)
 		return
 	}
 
-	body, err := readResponseBody(rsp)
-	if err != nil {
-		res.applyError(body, err)
-		return
-	}
-	if err = json.Unmarshal(body, &res.Data); err != nil {
-		res.applyError(body, err)
-		return
-	}
-	res.ready()
-	return res, nil
+	err = readAndUnmarshalResponse(rsp, &res.Response, &res.Data)
+	return
 }
 
 func poolIdsPL(pids []PoolID) io.Reader {
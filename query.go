@@ -0,0 +1,198 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// Filter operators supported by PostgREST.
+//
+// Eq    : equals.
+// Neq   : not equal.
+// Gt    : greater than.
+// Gte   : greater than or equal.
+// Lt    : less than.
+// Lte   : less than or equal.
+// Like  : LIKE operator, use * in place of %.
+// ILike : case insensitive LIKE operator, use * in place of %.
+// In    : one of a list of values, value must be slice or array.
+// Is    : checking for exact equality (null, true, false).
+const (
+	Eq    Operator = "eq"
+	Neq   Operator = "neq"
+	Gt    Operator = "gt"
+	Gte   Operator = "gte"
+	Lt    Operator = "lt"
+	Lte   Operator = "lte"
+	Like  Operator = "like"
+	ILike Operator = "ilike"
+	In    Operator = "in"
+	Is    Operator = "is"
+)
+
+type (
+	// Operator is PostgREST filter operator.
+	Operator string
+
+	// Query is PostgREST query used for horizontal (rows) and vertical
+	// (columns) filtering of list endpoints. Query is CallOption
+	// so it can be passed to any method of the API client.
+	//
+	// e.g. pools with live saturation over 90% ordered by active stake.
+	// koios.NewQuery().
+	// 	Where("live_saturation", koios.Gt, 0.9).
+	// 	Desc("active_stake")
+	Query struct {
+		columns []string
+		filters []queryFilter
+		order   []string
+		limit   *uint
+		offset  *uint
+	}
+
+	queryFilter struct {
+		column string
+		op     Operator
+		not    bool
+		value  interface{}
+	}
+)
+
+// NewQuery returns empty query.
+func NewQuery() *Query {
+	return &Query{}
+}
+
+// Select limits response to provided columns.
+func (q *Query) Select(columns ...string) *Query {
+	q.columns = append(q.columns, columns...)
+	return q
+}
+
+// Where filters rows where column matches value using operator op.
+func (q *Query) Where(column string, op Operator, value interface{}) *Query {
+	q.filters = append(q.filters, queryFilter{column: column, op: op, value: value})
+	return q
+}
+
+// WhereNot filters rows where column does not match value using operator op.
+func (q *Query) WhereNot(column string, op Operator, value interface{}) *Query {
+	q.filters = append(q.filters, queryFilter{column: column, op: op, not: true, value: value})
+	return q
+}
+
+// Asc orders rows by column in ascending order.
+func (q *Query) Asc(column string) *Query {
+	q.order = append(q.order, column+".asc")
+	return q
+}
+
+// Desc orders rows by column in descending order.
+func (q *Query) Desc(column string) *Query {
+	q.order = append(q.order, column+".desc")
+	return q
+}
+
+// Limit limits number of returned rows.
+func (q *Query) Limit(n uint) *Query {
+	q.limit = &n
+	return q
+}
+
+// Offset skips first n rows.
+func (q *Query) Offset(n uint) *Query {
+	q.offset = &n
+	return q
+}
+
+// Values returns query encoded as url.Values.
+func (q *Query) Values() url.Values {
+	v := url.Values{}
+	if q == nil {
+		return v
+	}
+	if len(q.columns) > 0 {
+		v.Set("select", strings.Join(q.columns, ","))
+	}
+	for _, f := range q.filters {
+		expr := string(f.op) + "." + formatQueryValue(f.op, f.value)
+		if f.not {
+			expr = "not." + expr
+		}
+		v.Add(f.column, expr)
+	}
+	if len(q.order) > 0 {
+		v.Set("order", strings.Join(q.order, ","))
+	}
+	if q.limit != nil {
+		v.Set("limit", fmt.Sprint(*q.limit))
+	}
+	if q.offset != nil {
+		v.Set("offset", fmt.Sprint(*q.offset))
+	}
+	return v
+}
+
+// withQuery returns params extended with provided queries.
+// Values of params take precedence over values set by queries.
+func withQuery(params url.Values, queries []*Query) url.Values {
+	if len(queries) == 0 {
+		return params
+	}
+	merged := url.Values{}
+	for k, v := range params {
+		merged[k] = v
+	}
+	for _, q := range queries {
+		for k, values := range q.Values() {
+			if _, ok := params[k]; ok {
+				continue
+			}
+			merged[k] = append(merged[k], values...)
+		}
+	}
+	return merged
+}
+
+// formatQueryValue formats filter value, list is formatted
+// as (a,b,c) for In operator.
+func formatQueryValue(op Operator, value interface{}) string {
+	if op != In {
+		return fmt.Sprint(value)
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return "(" + quoteQueryValue(fmt.Sprint(value)) + ")"
+	}
+	items := make([]string, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		items[i] = quoteQueryValue(fmt.Sprint(rv.Index(i).Interface()))
+	}
+	return "(" + strings.Join(items, ",") + ")"
+}
+
+// quoteQueryValue quotes list item containing PostgREST reserved characters.
+func quoteQueryValue(s string) string {
+	if !strings.ContainsAny(s, ",.:()\" ") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,70 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/howijd/koios-rest-go-client"
+)
+
+func TestQueryValues(t *testing.T) {
+	q := koios.NewQuery().
+		Select("tx_hash", "block_height").
+		Where("live_saturation", koios.Gt, 0.9).
+		Where("ticker", koios.In, []string{"ABC", "D,E"}).
+		WhereNot("retiring_epoch", koios.Is, "null").
+		Desc("active_stake").
+		Asc("ticker").
+		Limit(10).
+		Offset(20)
+
+	assert.Equal(t, url.Values{
+		"select":          {"tx_hash,block_height"},
+		"live_saturation": {"gt.0.9"},
+		"ticker":          {`in.(ABC,"D,E")`},
+		"retiring_epoch":  {"not.is.null"},
+		"order":           {"active_stake.desc,ticker.asc"},
+		"limit":           {"10"},
+		"offset":          {"20"},
+	}, q.Values())
+}
+
+func TestQueryIterator(t *testing.T) {
+	var queries []url.Values
+	api := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[]`))
+	}))
+
+	pools := api.PoolListIterator(context.Background(),
+		koios.NewQuery().Where("ticker", koios.Like, "A*").Offset(5))
+	assert.False(t, pools.Next())
+	assert.NoError(t, pools.Err())
+
+	if assert.Len(t, queries, 1) {
+		assert.Equal(t, "like.A*", queries[0].Get("ticker"))
+		// pagination of the iterator takes precedence.
+		assert.Equal(t, "0", queries[0].Get("offset"))
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy configures automatic retries of failed requests.
+//
+// GET and HEAD requests are always retried, POST requests are retried
+// since all POST endpoints of Koios API are lookups e.g. /tx_info,
+// /address_txs. Only exception is /submittx which is retried only
+// when RetrySubmitTx is enabled.
+type RetryPolicy struct {
+	// MaxAttempts is maximum number of attempts including the first one.
+	MaxAttempts int
+
+	// MinBackoff is delay before first retry, delay is doubled
+	// for every next retry.
+	MinBackoff time.Duration
+
+	// MaxBackoff is maximum delay between retries.
+	MaxBackoff time.Duration
+
+	// MaxRetryAfter is maximum delay requested by Retry-After header
+	// which is honored, MaxBackoff is used when it is 0. When server asks
+	// to wait longer, request is not retried and response is returned.
+	MaxRetryAfter time.Duration
+
+	// Jitter is fraction (0-1) of backoff delay which is randomized
+	// to avoid retrying requests from multiple clients in sync.
+	Jitter float64
+
+	// RetryOn is list of HTTP status codes which are retried.
+	RetryOn []int
+
+	// RetryServerErrors enables retrying any 5xx server error
+	// in addition to status codes listed in RetryOn.
+	RetryServerErrors bool
+
+	// RetrySubmitTx enables retrying of /submittx requests.
+	RetrySubmitTx bool
+}
+
+var (
+	jitterMux sync.Mutex
+	//nolint: gosec
+	jitterRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// DefaultRetryPolicy returns RetryPolicy retrying 429, 502, 503 and 504
+// responses and connection errors up to 4 attempts.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 4,
+		MinBackoff:  500 * time.Millisecond,
+		MaxBackoff:  10 * time.Second,
+		Jitter:      0.5,
+		RetryOn: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// Retry enables automatic retries of failed requests using provided policy.
+// Retry-After response header is honored when present, up to MaxRetryAfter.
+func Retry(policy RetryPolicy) Option {
+	return func(c *Client) error {
+		if policy.MaxAttempts < 1 {
+			return ErrRetryAttempts
+		}
+		if policy.Jitter < 0 || policy.Jitter > 1 {
+			return ErrRetryJitter
+		}
+		policy.RetryOn = append([]int(nil), policy.RetryOn...)
+		c.mux.Lock()
+		defer c.mux.Unlock()
+		c.retry = &policy
+		return nil
+	}
+}
+
+// allows reports whether requests with given method to given path
+// can be retried.
+func (p *RetryPolicy) allows(method, path string) bool {
+	return p.MaxAttempts > 1 && isIdempotent(method, path, p.RetrySubmitTx)
+}
+
+// isIdempotent reports whether request can be safely sent again.
+func isIdempotent(method, path string, submitTx bool) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return true
+	case http.MethodPost:
+		return path != "submittx" || submitTx
+	}
+	return false
+}
+
+func (p *RetryPolicy) shouldRetry(ctx context.Context, attempt int, rsp *http.Response, err error) bool {
+	if attempt >= p.MaxAttempts || ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return isRetryableErr(err)
+	}
+	if rsp == nil {
+		return false
+	}
+	if p.RetryServerErrors && rsp.StatusCode >= 500 {
+		return true
+	}
+	for _, code := range p.RetryOn {
+		if rsp.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// delay returns how long to wait before next attempt. It returns false
+// when Retry-After of the response exceeds MaxRetryAfter of the policy.
+func (p *RetryPolicy) delay(attempt int, rsp *http.Response) (time.Duration, bool) {
+	d := p.MinBackoff
+	for i := 1; i < attempt && i < 32 && (p.MaxBackoff == 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if p.Jitter > 0 && d > 0 {
+		jitterMux.Lock()
+		d -= time.Duration(jitterRand.Float64() * p.Jitter * float64(d))
+		jitterMux.Unlock()
+	}
+	if rsp != nil {
+		if ra, ok := parseRetryAfter(rsp.Header.Get("Retry-After")); ok && ra > d {
+			max := p.MaxRetryAfter
+			if max == 0 {
+				max = p.MaxBackoff
+			}
+			if max > 0 && ra > max {
+				return 0, false
+			}
+			d = ra
+		}
+	}
+	return d, true
+}
+
+// parseRetryAfter parses Retry-After header which
+// is either delay in seconds or HTTP date.
+func parseRetryAfter(header string) (time.Duration, bool) {
+	if len(header) == 0 {
+		return 0, false
+	}
+	if sec, err := strconv.Atoi(header); err == nil {
+		if sec < 0 {
+			return 0, false
+		}
+		return time.Duration(sec) * time.Second, true
+	}
+	t, err := http.ParseTime(header)
+	if err != nil {
+		return 0, false
+	}
+	d := time.Until(t)
+	if d < 0 {
+		d = 0
+	}
+	return d, true
+}
+
+// isRetryableErr reports whether transport error is transient.
+// Caller must ensure that request context is not done.
+func isRetryableErr(err error) bool {
+	if errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var nerr net.Error
+	return errors.As(err, &nerr) && nerr.Timeout()
+}
+
+// sleepCtx blocks for duration d or until ctx is done.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
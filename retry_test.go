@@ -0,0 +1,101 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/howijd/koios-rest-go-client"
+)
+
+func testRetryPolicy() koios.RetryPolicy {
+	policy := koios.DefaultRetryPolicy()
+	policy.MinBackoff = time.Millisecond
+	policy.MaxBackoff = 5 * time.Millisecond
+	return policy
+}
+
+func TestRetryTransientErrors(t *testing.T) {
+	var calls int32
+	api := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Contains(t, string(body), "_tx_hashes", "payload should be resent on retry")
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"tx_hash":"abc","num_confirmations":10}]`))
+	}))
+	assert.NoError(t, koios.Retry(testRetryPolicy())(api))
+
+	res, err := api.GetTxsStatuses(context.Background(), []koios.TxHash{"abc"})
+	assert.NoError(t, err)
+	assert.Nil(t, res.Error)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	if assert.Len(t, res.Data, 1) {
+		assert.Equal(t, uint64(10), res.Data[0].NumConfirmations)
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	assert.Equal(t, uint64(3), api.TotalRequests())
+}
+
+func TestRetrySubmitTxNotRetried(t *testing.T) {
+	var calls int32
+	api := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	assert.NoError(t, koios.Retry(testRetryPolicy())(api))
+
+	_, _ = api.SubmitSignedTx(context.Background(), koios.TxBodyJSON{CborHex: "84a3"})
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "submittx must not be retried by default")
+}
+
+func TestRetryAfterExceedsPolicy(t *testing.T) {
+	var calls int32
+	api := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "86400")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	policy := testRetryPolicy()
+	policy.MaxRetryAfter = time.Second
+	assert.NoError(t, koios.Retry(policy)(api))
+
+	start := time.Now()
+	res, err := api.GetTip(context.Background())
+	assert.ErrorIs(t, err, koios.ErrRateLimited)
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// MaxBackoff limits Retry-After when MaxRetryAfter is not set.
+	policy.MaxRetryAfter = 0
+	assert.NoError(t, koios.Retry(policy)(api))
+	_, err = api.GetTip(context.Background())
+	assert.ErrorIs(t, err, koios.ErrRateLimited)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
@@ -18,9 +18,7 @@ package koios
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"net/http"
 	"net/url"
 )
 
@@ -87,67 +85,57 @@ type (
 
 // GetScriptList returns the list of all existing script
 // hashes along with their creation transaction hashes.
-func (c *Client) GetScriptList(ctx context.Context) (res *ScriptListResponse, err error) {
-	res = &ScriptListResponse{}
-	rsp, err := c.request(ctx, &res.Response, "GET", "/script_list", nil, nil, nil)
-	if err != nil {
-		res.applyError(nil, err)
-		return
-	}
+// Use ScriptListIterator to walk through all pages.
+func (c *Client) GetScriptList(ctx context.Context, opts ...CallOption) (res *ScriptListResponse, err error) {
+	return c.getScriptList(ctx, nil, opts...)
+}
 
-	body, err := readResponseBody(rsp)
-	if err != nil {
-		res.applyError(body, err)
-		return
-	}
+// ScriptListIterator returns iterator over all existing scripts.
+func (c *Client) ScriptListIterator(ctx context.Context, opts ...CallOption) *Iterator[ScriptListItem] {
+	return newIterator(ctx, c, func(ctx context.Context, query url.Values) ([]ScriptListItem, *Response, error) {
+		res, err := c.getScriptList(ctx, query, opts...)
+		return res.Data, &res.Response, err
+	})
+}
 
-	if err = json.Unmarshal(body, &res.Data); err != nil {
-		res.applyError(body, err)
+func (c *Client) getScriptList(
+	ctx context.Context,
+	query url.Values,
+	opts ...CallOption,
+) (res *ScriptListResponse, err error) {
+	res = &ScriptListResponse{}
+	rsp, err := c.request(ctx, &res.Response, "GET", "/script_list", nil, query, nil, opts...)
+	if err != nil {
 		return
 	}
 
-	if rsp.StatusCode != http.StatusOK {
-		res.applyError(body, err)
-		return
-	}
-	return res, nil
+	err = readAndUnmarshalResponse(rsp, &res.Response, &res.Data)
+	return
 }
 
 // GetScriptRedeemers returns a list of all redeemers for a given script hash.
 func (c *Client) GetScriptRedeemers(
 	ctx context.Context,
 	sh ScriptHash,
+	opts ...CallOption,
 ) (res *ScriptRedeemersResponse, err error) {
 	res = &ScriptRedeemersResponse{}
 
 	params := url.Values{}
 	params.Set("_script_hash", fmt.Sprint(sh))
 
-	rsp, err := c.request(ctx, &res.Response, "GET", "/script_redeemers", nil, params, nil)
+	rsp, err := c.request(ctx, &res.Response, "GET", "/script_redeemers", nil, params, nil, opts...)
 	if err != nil {
-		res.applyError(nil, err)
 		return
 	}
 
-	body, err := readResponseBody(rsp)
-	if err != nil {
-		res.applyError(body, err)
-		return
-	}
 	r := []ScriptRedeemers{}
 
-	if err = json.Unmarshal(body, &r); err != nil {
-		res.applyError(body, err)
-		return
-	}
-
-	if rsp.StatusCode != http.StatusOK {
-		res.applyError(body, err)
+	if err = readAndUnmarshalResponse(rsp, &res.Response, &r); err != nil {
 		return
 	}
 	if len(r) == 1 {
 		res.Data = &r[0]
 	}
-	res.ready()
-	return res, nil
+	return
 }
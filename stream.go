@@ -0,0 +1,154 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// StreamAssetAddressList calls fn for each address holding a given asset.
+// Holders are decoded one by one while reading the response and all pages
+// are requested, so memory use does not grow with number of holders.
+// Streaming stops when fn returns error which is then returned.
+// Returned response is response of the last requested page.
+func (c *Client) StreamAssetAddressList(
+	ctx context.Context,
+	policy PolicyID,
+	name AssetName,
+	fn func(AssetHolder) error,
+	opts ...CallOption,
+) (*Response, error) {
+	params := url.Values{}
+	params.Set("_asset_policy", string(policy))
+	params.Set("_asset_name", string(name))
+	return stream(ctx, c, "/asset_address_list", params, fn, opts)
+}
+
+// StreamPoolDelegators calls fn for each delegator of a given pool
+// and optional epoch (current if omitted). Delegators are decoded one by one
+// while reading the response and all pages are requested, so memory use
+// does not grow with number of delegators.
+// Streaming stops when fn returns error which is then returned.
+// Returned response is response of the last requested page.
+func (c *Client) StreamPoolDelegators(
+	ctx context.Context,
+	pid PoolID,
+	epoch *EpochNo,
+	fn func(PoolDelegator) error,
+	opts ...CallOption,
+) (*Response, error) {
+	params := url.Values{}
+	params.Set("_pool_bech32", string(pid))
+	if epoch != nil {
+		params.Set("_epoch_no", fmt.Sprint(*epoch))
+	}
+	return stream(ctx, c, "/pool_delegators", params, fn, opts)
+}
+
+// stream requests all pages of GET endpoint decoding
+// items of each page one by one.
+func stream[T any](
+	ctx context.Context,
+	c *Client,
+	path string,
+	params url.Values,
+	fn func(T) error,
+	opts []CallOption,
+) (*Response, error) {
+	c.mux.RLock()
+	pageSize := c.pageSize
+	c.mux.RUnlock()
+
+	var offset uint
+	for {
+		query := url.Values{}
+		for k, v := range params {
+			query[k] = v
+		}
+		query.Set("offset", fmt.Sprint(offset))
+		query.Set("limit", fmt.Sprint(pageSize))
+
+		res := &Response{}
+		rsp, err := c.request(ctx, res, "GET", path, nil, query, nil, opts...)
+		if err != nil {
+			return res, err
+		}
+		n, err := decodeStream(rsp, res, fn)
+		if err != nil {
+			return res, err
+		}
+		offset += n
+		total := int64(-1)
+		if res.PageInfo != nil {
+			total = res.PageInfo.Total
+		}
+		if isLastPage(int(n), offset, total) {
+			return res, nil
+		}
+	}
+}
+
+// decodeStream decodes JSON array from response body calling fn
+// for each element. It returns number of decoded elements.
+func decodeStream[T any](rsp *http.Response, res *Response, fn func(T) error) (uint, error) {
+	// error responses and non json responses are handled as usual.
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 ||
+		!strings.Contains(rsp.Header.Get("Content-Type"), "json") {
+		return 0, readAndUnmarshalResponse(rsp, res, nil)
+	}
+	defer func() { _ = rsp.Body.Close() }()
+
+	dec := json.NewDecoder(rsp.Body)
+	if err := expectDelim(dec, '['); err != nil {
+		return 0, res.applyError(nil, err)
+	}
+
+	var n uint
+	for dec.More() {
+		var item T
+		if err := dec.Decode(&item); err != nil {
+			return n, res.applyError(nil, err)
+		}
+		n++
+		if err := fn(item); err != nil {
+			return n, res.applyError(nil, err)
+		}
+	}
+
+	if err := expectDelim(dec, ']'); err != nil {
+		return n, res.applyError(nil, err)
+	}
+	res.ready()
+	return n, nil
+}
+
+// expectDelim reads next token and checks that it is delim.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("%w: expected %s got %v", ErrResponseIsNotJSON, delim, tok)
+	}
+	return nil
+}
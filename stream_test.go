@@ -0,0 +1,64 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/howijd/koios-rest-go-client"
+)
+
+func TestStreamAssetAddressList(t *testing.T) {
+	api := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		off, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, "[")
+		// 3 pages, last one partial, followed by empty page.
+		n := 2005 - off
+		if n > 1000 {
+			n = 1000
+		} else if n < 0 {
+			n = 0
+		}
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"payment_address":"addr%d","quantity":"%d"}`, off+i, i)
+		}
+		fmt.Fprint(w, "]")
+	}))
+
+	var cnt int
+	_, err := api.StreamAssetAddressList(context.Background(), "p", "n", func(h koios.AssetHolder) error {
+		cnt++
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2005, cnt)
+
+	stop := errors.New("stop")
+	_, err = api.StreamAssetAddressList(context.Background(), "p", "n", func(h koios.AssetHolder) error { return stop })
+	assert.ErrorIs(t, err, stop)
+}
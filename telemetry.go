@@ -0,0 +1,356 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets are upper bounds of latency histogram buckets
+// used by Metrics.
+var DefaultLatencyBuckets = []time.Duration{
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+type (
+	// Telemetry receives instrumentation of API calls made by the client.
+	// It is exporter agnostic, see Metrics for in-memory implementation
+	// and ./otelkoios for OpenTelemetry adapter.
+	// Implementation must be safe for concurrent use.
+	Telemetry interface {
+		// StartCall is called before API call is sent. Returned context
+		// is used for the request. CallSpan is ended when call completes,
+		// including all retries and reading of the response body.
+		StartCall(ctx context.Context, endpoint, method string) (context.Context, CallSpan)
+	}
+
+	// CallSpan is single instrumented API call.
+	CallSpan interface {
+		// End is called once when the call has completed.
+		End(info CallInfo)
+	}
+
+	// CallInfo describes completed API call.
+	CallInfo struct {
+		// Endpoint is relative API path e.g. "tip", "tx_info".
+		Endpoint string
+
+		// Method is HTTP method of the call.
+		Method string
+
+		// URL is request url of the last attempt.
+		URL string
+
+		// StatusCode of the last response, 0 when no response was received.
+		StatusCode int
+
+		// Attempts is number of requests sent including retries
+		// and failovers to other instances.
+		Attempts int
+
+		// BytesSent is size of request payload.
+		BytesSent int64
+
+		// BytesReceived is number of bytes of response body read.
+		BytesReceived int64
+
+		// Duration of the call until response body was closed.
+		Duration time.Duration
+
+		// Err is transport error of the call if any.
+		Err error
+
+		// Cached is true when response was served from response cache
+		// without sending request, see ResponseCache.
+		Cached bool
+	}
+
+	// Metrics is in-memory Telemetry aggregating
+	// counters and latency histograms per endpoint.
+	Metrics struct {
+		mux       sync.Mutex
+		buckets   []time.Duration
+		endpoints map[string]*EndpointMetrics
+	}
+
+	// EndpointMetrics are aggregated metrics of single endpoint.
+	EndpointMetrics struct {
+		// Calls is number of calls made.
+		Calls uint64 `json:"calls"`
+
+		// Errors is number of calls which failed
+		// or were responded with status code >= 400.
+		Errors uint64 `json:"errors"`
+
+		// Retries is number of retries and failovers.
+		Retries uint64 `json:"retries"`
+
+		// CacheHits is number of calls served from response cache,
+		// they are counted in Calls but not observed in Latency.
+		CacheHits uint64 `json:"cache_hits"`
+
+		// BytesSent is total size of request payloads.
+		BytesSent int64 `json:"bytes_sent"`
+
+		// BytesReceived is total size of response bodies.
+		BytesReceived int64 `json:"bytes_received"`
+
+		// StatusCodes is number of responses per status code.
+		StatusCodes map[int]uint64 `json:"status_codes"`
+
+		// Latency histogram of the calls.
+		Latency Histogram `json:"latency"`
+	}
+
+	// Histogram is latency histogram, Counts[i] is number of observations
+	// less or equal to Bounds[i], last element of Counts is number
+	// of observations greater than last bound.
+	Histogram struct {
+		Bounds []time.Duration `json:"bounds"`
+		Counts []uint64        `json:"counts"`
+		Sum    time.Duration   `json:"sum"`
+		Count  uint64          `json:"count"`
+	}
+
+	metricsSpan struct {
+		m        *Metrics
+		endpoint string
+	}
+
+	// callTracker tracks instrumented call until its response body is closed.
+	callTracker struct {
+		once  sync.Once
+		span  CallSpan
+		info  CallInfo
+		start time.Time
+		sent  *countingReader
+	}
+
+	countingReader struct {
+		r io.Reader
+		n int64
+	}
+
+	trackedBody struct {
+		io.ReadCloser
+		tracker *callTracker
+	}
+)
+
+// Instrumentation enables instrumentation of API calls with Telemetry.
+func Instrumentation(t Telemetry) Option {
+	return func(c *Client) error {
+		if t == nil {
+			return ErrTelemetryNil
+		}
+		c.mux.Lock()
+		defer c.mux.Unlock()
+		c.telemetry = t
+		return nil
+	}
+}
+
+// NewMetrics returns in-memory metrics collector. Latency histograms
+// use provided bucket bounds or DefaultLatencyBuckets when none provided.
+func NewMetrics(buckets ...time.Duration) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	b := append([]time.Duration(nil), buckets...)
+	sort.Slice(b, func(i, j int) bool { return b[i] < b[j] })
+	return &Metrics{
+		buckets:   b,
+		endpoints: make(map[string]*EndpointMetrics),
+	}
+}
+
+// StartCall implements Telemetry interface.
+func (m *Metrics) StartCall(ctx context.Context, endpoint, method string) (context.Context, CallSpan) {
+	return ctx, &metricsSpan{m: m, endpoint: endpoint}
+}
+
+// Snapshot returns copy of collected metrics keyed by endpoint.
+func (m *Metrics) Snapshot() map[string]EndpointMetrics {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	snap := make(map[string]EndpointMetrics, len(m.endpoints))
+	for endpoint, em := range m.endpoints {
+		cp := *em
+		cp.StatusCodes = make(map[int]uint64, len(em.StatusCodes))
+		for code, n := range em.StatusCodes {
+			cp.StatusCodes[code] = n
+		}
+		cp.Latency.Bounds = append([]time.Duration(nil), em.Latency.Bounds...)
+		cp.Latency.Counts = append([]uint64(nil), em.Latency.Counts...)
+		snap[endpoint] = cp
+	}
+	return snap
+}
+
+// Reset clears collected metrics.
+func (m *Metrics) Reset() {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.endpoints = make(map[string]*EndpointMetrics)
+}
+
+// End implements CallSpan interface.
+func (s *metricsSpan) End(info CallInfo) {
+	m := s.m
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	em, ok := m.endpoints[s.endpoint]
+	if !ok {
+		em = &EndpointMetrics{
+			StatusCodes: make(map[int]uint64),
+			Latency: Histogram{
+				Bounds: m.buckets,
+				Counts: make([]uint64, len(m.buckets)+1),
+			},
+		}
+		m.endpoints[s.endpoint] = em
+	}
+	em.Calls++
+	if info.Err != nil || info.StatusCode >= 400 {
+		em.Errors++
+	}
+	if info.Attempts > 1 {
+		em.Retries += uint64(info.Attempts - 1)
+	}
+	if info.StatusCode != 0 {
+		em.StatusCodes[info.StatusCode]++
+	}
+	em.BytesSent += info.BytesSent
+	em.BytesReceived += info.BytesReceived
+	if info.Cached {
+		em.CacheHits++
+		return
+	}
+	em.Latency.observe(info.Duration)
+}
+
+func (h *Histogram) observe(d time.Duration) {
+	i := sort.Search(len(h.Bounds), func(i int) bool { return d <= h.Bounds[i] })
+	h.Counts[i]++
+	h.Sum += d
+	h.Count++
+}
+
+// startTracking starts instrumentation of the call, returned body
+// must be used for the request.
+func startTracking(
+	ctx context.Context,
+	t Telemetry,
+	endpoint string,
+	method string,
+	body io.Reader,
+) (context.Context, *callTracker, io.Reader) {
+	ctx, span := t.StartCall(ctx, endpoint, method)
+	tracker := &callTracker{
+		span:  span,
+		start: time.Now(),
+		info: CallInfo{
+			Endpoint: endpoint,
+			Method:   method,
+		},
+	}
+	if body != nil {
+		tracker.sent = &countingReader{r: body}
+		body = tracker.sent
+	}
+	return ctx, tracker, body
+}
+
+// done records result of the call. When response is returned span is
+// ended when its body is closed, otherwise span is ended immediately.
+func (t *callTracker) done(call *Call, rsp *http.Response, err error) *http.Response {
+	t.info.Attempts = call.Attempt
+	t.info.Err = err
+	if call.Request != nil {
+		t.info.URL = call.Request.URL.String()
+	}
+	if t.sent != nil {
+		t.info.BytesSent = t.sent.n
+	}
+	if rsp == nil {
+		t.end()
+		return nil
+	}
+	t.info.StatusCode = rsp.StatusCode
+	rsp.Body = &trackedBody{ReadCloser: rsp.Body, tracker: t}
+	return rsp
+}
+
+// trackCached records call served from response cache.
+func trackCached(ctx context.Context, t Telemetry, endpoint, method, requrl string) {
+	start := time.Now()
+	_, span := t.StartCall(ctx, endpoint, method)
+	span.End(CallInfo{
+		Endpoint:   endpoint,
+		Method:     method,
+		URL:        requrl,
+		StatusCode: http.StatusOK,
+		Duration:   time.Since(start),
+		Cached:     true,
+	})
+}
+
+func (t *callTracker) end() {
+	t.once.Do(func() {
+		t.info.Duration = time.Since(t.start)
+		t.span.End(t.info)
+	})
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// Close closes underlying reader when it is io.Closer
+// e.g. request body which was not sent.
+func (r *countingReader) Close() error {
+	if c, ok := r.r.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (b *trackedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.tracker.info.BytesReceived += int64(n)
+	return n, err
+}
+
+func (b *trackedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.tracker.end()
+	return err
+}
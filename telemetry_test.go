@@ -0,0 +1,85 @@
+// Copyright 2022 The Howijd.Network Authors
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//   or LICENSE file in repository root.
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package koios_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/howijd/koios-rest-go-client"
+)
+
+func TestMetrics(t *testing.T) {
+	var calls int
+	metrics := koios.NewMetrics()
+	api := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"block_no":1}]`))
+	}),
+		koios.Instrumentation(metrics),
+		koios.Retry(koios.RetryPolicy{
+			MaxAttempts: 2,
+			MinBackoff:  time.Millisecond,
+			RetryOn:     []int{http.StatusServiceUnavailable},
+		}),
+	)
+
+	_, err := api.GetTip(context.Background())
+	assert.NoError(t, err)
+
+	tip, ok := metrics.Snapshot()["tip"]
+	if assert.True(t, ok) {
+		assert.Equal(t, uint64(1), tip.Calls)
+		assert.Equal(t, uint64(0), tip.Errors)
+		assert.Equal(t, uint64(1), tip.Retries)
+		assert.Equal(t, uint64(1), tip.StatusCodes[http.StatusOK])
+		assert.Equal(t, int64(16), tip.BytesReceived)
+		assert.Equal(t, uint64(1), tip.Latency.Count)
+	}
+
+	_, err = koios.New(koios.Instrumentation(nil))
+	assert.ErrorIs(t, err, koios.ErrTelemetryNil)
+}
+
+func TestMetricsCacheHits(t *testing.T) {
+	metrics := koios.NewMetrics()
+	api, srv := newCacheTestClient(t, koios.DefaultCachePolicy(), koios.Instrumentation(metrics))
+
+	for i := 0; i < 3; i++ {
+		res, err := api.GetGenesis(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, i > 0, res.Cached)
+	}
+	assert.Equal(t, 1, srv.count("genesis"))
+
+	genesis, ok := metrics.Snapshot()["genesis"]
+	if assert.True(t, ok) {
+		assert.Equal(t, uint64(3), genesis.Calls)
+		assert.Equal(t, uint64(2), genesis.CacheHits)
+		assert.Equal(t, uint64(3), genesis.StatusCodes[http.StatusOK])
+		assert.Equal(t, uint64(1), genesis.Latency.Count)
+	}
+}
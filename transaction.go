@@ -21,9 +21,11 @@ import (
 	"context"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 )
 
 type (
@@ -248,9 +250,9 @@ type (
 )
 
 // GetTxInfo returns detailed information about transaction.
-func (c *Client) GetTxInfo(ctx context.Context, tx TxHash) (res *TxInfoResponse, err error) {
+func (c *Client) GetTxInfo(ctx context.Context, tx TxHash, opts ...CallOption) (res *TxInfoResponse, err error) {
 	res = &TxInfoResponse{}
-	rsp, err := c.GetTxsInfos(ctx, []TxHash{tx})
+	rsp, err := c.GetTxsInfos(ctx, []TxHash{tx}, opts...)
 	res.Response = rsp.Response
 	if len(rsp.Data) == 1 {
 		res.Data = &rsp.Data[0]
@@ -259,65 +261,103 @@ func (c *Client) GetTxInfo(ctx context.Context, tx TxHash) (res *TxInfoResponse,
 }
 
 // GetTxsInfos returns detailed information about transaction(s).
-func (c *Client) GetTxsInfos(ctx context.Context, txs []TxHash) (res *TxsInfosResponse, err error) {
-	res = &TxsInfosResponse{}
-	if len(txs) == 0 {
-		err = ErrNoTxHash
-		res.applyError(nil, err)
-		return
+// When ResponseCache is enabled final transactions are served from cache,
+// on *BulkError response data contains cached and successfully fetched transactions.
+func (c *Client) GetTxsInfos(
+	ctx context.Context,
+	txs []TxHash,
+	opts ...CallOption,
+) (res *TxsInfosResponse, err error) {
+	rc := c.responseCache(opts)
+	if rc == nil || len(txs) == 0 {
+		return c.getTxsInfos(ctx, txs, opts...)
+	}
+
+	cached := make(map[TxHash]TxInfo)
+	var missing []TxHash
+	for _, tx := range txs {
+		info := TxInfo{}
+		if rc.get(rc.key("tx_info", string(tx)), &info) {
+			cached[tx] = info
+		} else {
+			missing = append(missing, tx)
+		}
+	}
+
+	if len(missing) == 0 {
+		res = &TxsInfosResponse{}
+		c.applyCached(ctx, rc, &res.Response, "POST", "tx_info", nil)
+	} else {
+		// on partial failure (*BulkError) data of successful chunks
+		// is still cached and merged with cached transactions.
+		var bulkErr *BulkError
+		res, err = c.getTxsInfos(ctx, missing, opts...)
+		if err != nil && !errors.As(err, &bulkErr) {
+			return
+		}
+		var tip *Tip
+		if len(res.Data) > 0 {
+			tip, _ = c.cachedTip(ctx, rc)
+		}
+		for _, info := range res.Data {
+			if rc.isFinal(tip, info.BlockHeight) {
+				rc.set(rc.key("tx_info", string(info.TxHash)), info)
+			}
+			cached[info.TxHash] = info
+		}
+	}
+
+	// preserve order of requested transactions.
+	res.Data = res.Data[:0]
+	for _, tx := range txs {
+		if info, ok := cached[tx]; ok {
+			res.Data = append(res.Data, info)
+		}
 	}
+	return
+}
 
-	rsp, err := c.request(ctx, &res.Response, "POST", "/tx_info", txHashesPL(txs), nil, nil)
-	if err != nil {
-		res.applyError(nil, err)
+func (c *Client) getTxsInfos(
+	ctx context.Context,
+	txs []TxHash,
+	opts ...CallOption,
+) (res *TxsInfosResponse, err error) {
+	res = &TxsInfosResponse{}
+	if len(txs) == 0 {
+		err = res.applyError(nil, ErrNoTxHash)
 		return
 	}
 
-	body, err := readResponseBody(rsp)
-	if err != nil {
-		res.applyError(body, err)
-		return
-	}
-	if err = json.Unmarshal(body, &res.Data); err != nil {
-		res.applyError(body, err)
-		return
-	}
-	res.ready()
-	return res, nil
+	res.Data, err = bulk(ctx, c, &res.Response, txs,
+		func(v TxInfo) TxHash { return v.TxHash },
+		postChunk[TxHash, TxInfo](c, "/tx_info", txHashesPL, opts),
+	)
+	return
 }
 
 // GetTxsUTxOs returns UTxO set (inputs/outputs) of transactions.
-func (c *Client) GetTxsUTxOs(ctx context.Context, txs []TxHash) (res *TxUTxOsResponse, err error) {
+func (c *Client) GetTxsUTxOs(ctx context.Context, txs []TxHash, opts ...CallOption) (res *TxUTxOsResponse, err error) {
 	res = &TxUTxOsResponse{}
 	if len(txs) == 0 {
-		err = ErrNoTxHash
-		res.applyError(nil, err)
+		err = res.applyError(nil, ErrNoTxHash)
 		return
 	}
 
-	rsp, err := c.request(ctx, &res.Response, "POST", "/tx_utxos", txHashesPL(txs), nil, nil)
-	if err != nil {
-		res.applyError(nil, err)
-		return
-	}
-
-	body, err := readResponseBody(rsp)
-	if err != nil {
-		res.applyError(body, err)
-		return
-	}
-	if err = json.Unmarshal(body, &res.Data); err != nil {
-		res.applyError(body, err)
-		return
-	}
-	res.ready()
-	return res, nil
+	res.Data, err = bulk(ctx, c, &res.Response, txs,
+		func(v UTxO) TxHash { return v.TxHash },
+		postChunk[TxHash, UTxO](c, "/tx_utxos", txHashesPL, opts),
+	)
+	return
 }
 
 // GetTxMetadata returns metadata information (if any) for given transaction.
-func (c *Client) GetTxMetadata(ctx context.Context, tx TxHash) (res *TxMetadataResponse, err error) {
+func (c *Client) GetTxMetadata(
+	ctx context.Context,
+	tx TxHash,
+	opts ...CallOption,
+) (res *TxMetadataResponse, err error) {
 	res = &TxMetadataResponse{}
-	rsp, err := c.GetTxsMetadata(ctx, []TxHash{tx})
+	rsp, err := c.GetTxsMetadata(ctx, []TxHash{tx}, opts...)
 	res.Response = rsp.Response
 	if len(rsp.Data) == 1 {
 		res.Data = &rsp.Data[0]
@@ -326,94 +366,83 @@ func (c *Client) GetTxMetadata(ctx context.Context, tx TxHash) (res *TxMetadataR
 }
 
 // GetTxsInfos returns detailed information about transaction(s).
-func (c *Client) GetTxsMetadata(ctx context.Context, txs []TxHash) (res *TxsMetadataResponse, err error) {
+func (c *Client) GetTxsMetadata(
+	ctx context.Context,
+	txs []TxHash,
+	opts ...CallOption,
+) (res *TxsMetadataResponse, err error) {
 	res = &TxsMetadataResponse{}
 	if len(txs) == 0 {
-		err = ErrNoTxHash
-		res.applyError(nil, err)
+		err = res.applyError(nil, ErrNoTxHash)
 		return
 	}
 
-	rsp, err := c.request(ctx, &res.Response, "POST", "/tx_metadata", txHashesPL(txs), nil, nil)
-	if err != nil {
-		res.applyError(nil, err)
-		return
-	}
+	res.Data, err = bulk(ctx, c, &res.Response, txs,
+		func(v TxMetadata) TxHash { return v.TxHash },
+		postChunk[TxHash, TxMetadata](c, "/tx_metadata", txHashesPL, opts),
+	)
+	return
+}
 
-	body, err := readResponseBody(rsp)
-	if err != nil {
-		res.applyError(body, err)
-		return
-	}
-	if err = json.Unmarshal(body, &res.Data); err != nil {
-		res.applyError(body, err)
-		return
-	}
-	res.ready()
-	return res, nil
+// GetTxMetaLabels retruns a list of all transaction metalabels.
+// Use TxMetaLabelsIterator to walk through all pages.
+func (c *Client) GetTxMetaLabels(ctx context.Context, opts ...CallOption) (res *TxMetaLabelsResponse, err error) {
+	return c.getTxMetaLabels(ctx, nil, opts...)
+}
+
+// TxMetaLabelsIterator returns iterator over all transaction metalabels.
+func (c *Client) TxMetaLabelsIterator(ctx context.Context, opts ...CallOption) *Iterator[TxMetalabel] {
+	return newIterator(ctx, c, func(ctx context.Context, query url.Values) ([]TxMetalabel, *Response, error) {
+		res, err := c.getTxMetaLabels(ctx, query, opts...)
+		return res.Data, &res.Response, err
+	})
 }
 
-// GetTxMetadataLabels retruns a list of all transaction metalabels.
-func (c *Client) GetTxMetaLabels(ctx context.Context) (res *TxMetaLabelsResponse, err error) {
+func (c *Client) getTxMetaLabels(
+	ctx context.Context,
+	query url.Values,
+	opts ...CallOption,
+) (res *TxMetaLabelsResponse, err error) {
 	res = &TxMetaLabelsResponse{}
-	rsp, err := c.request(ctx, &res.Response, "GET", "/tx_metalabels", nil, nil, nil)
+	rsp, err := c.request(ctx, &res.Response, "GET", "/tx_metalabels", nil, query, nil, opts...)
 	if err != nil {
-		res.applyError(nil, err)
 		return
 	}
 
-	body, err := readResponseBody(rsp)
-	if err != nil {
-		res.applyError(body, err)
-		return
-	}
-	if err = json.Unmarshal(body, &res.Data); err != nil {
-		res.applyError(body, err)
-		return
-	}
-	res.ready()
-	return res, nil
+	err = readAndUnmarshalResponse(rsp, &res.Response, &res.Data)
+	return
 }
 
 // SubmitSignedTx Submit an transaction to the network.
-func (c *Client) SubmitSignedTx(ctx context.Context, stx TxBodyJSON) (res *SubmitSignedTxResponse, err error) {
+func (c *Client) SubmitSignedTx(
+	ctx context.Context,
+	stx TxBodyJSON,
+	opts ...CallOption,
+) (res *SubmitSignedTxResponse, err error) {
 	var cborb []byte
 	res = &SubmitSignedTxResponse{}
 
 	cborb, err = hex.DecodeString(stx.CborHex)
 	if err != nil {
+		err = res.applyError(nil, err)
 		return
 	}
 
 	h := http.Header{}
 	h.Set("Content-Type", "application/cbor")
 	h.Set("Content-Length", fmt.Sprint(len(cborb)))
-	rsp, err := c.request(ctx, &res.Response, "POST", "/submittx", bytes.NewBuffer(cborb), nil, h)
-
-	if err != nil {
-		res.applyError(nil, err)
-		return
-	}
-	body, err := readResponseBody(rsp)
-
+	rsp, err := c.request(ctx, &res.Response, "POST", "/submittx", bytes.NewBuffer(cborb), nil, h, opts...)
 	if err != nil {
-		res.applyError(body, err)
 		return
 	}
-
-	if err = json.Unmarshal(body, &res.Data); err != nil {
-		res.applyError(body, err)
-		return
-	}
-
-	res.ready()
-	return res, nil
+	err = readAndUnmarshalResponse(rsp, &res.Response, &res.Data)
+	return
 }
 
 // GetTxInfo returns detailed information about transaction.
-func (c *Client) GetTxStatus(ctx context.Context, tx TxHash) (res *TxStatusResponse, err error) {
+func (c *Client) GetTxStatus(ctx context.Context, tx TxHash, opts ...CallOption) (res *TxStatusResponse, err error) {
 	res = &TxStatusResponse{}
-	rsp, err := c.GetTxsStatuses(ctx, []TxHash{tx})
+	rsp, err := c.GetTxsStatuses(ctx, []TxHash{tx}, opts...)
 	res.Response = rsp.Response
 	if len(rsp.Data) == 1 {
 		res.Data = &rsp.Data[0]
@@ -422,31 +451,22 @@ func (c *Client) GetTxStatus(ctx context.Context, tx TxHash) (res *TxStatusRespo
 }
 
 // GetTxsInfos returns detailed information about transaction(s).
-func (c *Client) GetTxsStatuses(ctx context.Context, txs []TxHash) (res *TxsStatusesResponse, err error) {
+func (c *Client) GetTxsStatuses(
+	ctx context.Context,
+	txs []TxHash,
+	opts ...CallOption,
+) (res *TxsStatusesResponse, err error) {
 	res = &TxsStatusesResponse{}
 	if len(txs) == 0 {
-		err = ErrNoTxHash
-		res.applyError(nil, err)
+		err = res.applyError(nil, ErrNoTxHash)
 		return
 	}
 
-	rsp, err := c.request(ctx, &res.Response, "POST", "/tx_status", txHashesPL(txs), nil, nil)
-	if err != nil {
-		res.applyError(nil, err)
-		return
-	}
-
-	body, err := readResponseBody(rsp)
-	if err != nil {
-		res.applyError(body, err)
-		return
-	}
-	if err = json.Unmarshal(body, &res.Data); err != nil {
-		res.applyError(body, err)
-		return
-	}
-	res.ready()
-	return res, nil
+	res.Data, err = bulk(ctx, c, &res.Response, txs,
+		func(v TxStatus) TxHash { return v.TxHash },
+		postChunk[TxHash, TxStatus](c, "/tx_status", txHashesPL, opts),
+	)
+	return
 }
 
 func txHashesPL(txs []TxHash) io.Reader {